@@ -1,164 +1,334 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
-	"time"
+	"syscall"
 
 	// IMPORT PATH HARUS SESUAI DENGAN go.mod
+	"backend/internal/cache"
+	"backend/internal/config"
+	"backend/internal/database"
 	handlers "backend/internal/handler"
+	"backend/internal/middleware"
+	"backend/internal/migrations"
 	"backend/internal/models"
 	"backend/internal/repository"
 	services "backend/internal/service"
+	"backend/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	jwtware "github.com/gofiber/jwt/v3"
-	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
-	// 1. Setup Env & DB
-	godotenv.Load()
-	dsn := os.Getenv("DATABASE_URL")
+	// 1. Setup Config & DB
+	// Default <- config.yaml <- env/.env; semua key yang salah dilaporkan sekaligus
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Fallback jika DSN kosong agar tidak panic mendadak
-	if dsn == "" {
-		log.Fatal("DATABASE_URL is not set. Check your .env file")
+	// DB_DRIVER=sqlite menjalankan semuanya tanpa database eksternal;
+	// DATABASE_URL lalu berisi path file (default dokterbubung.db)
+	dbConfig := database.Config{
+		Driver:          cfg.Database.Driver,
+		DSN:             cfg.Database.URL,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+	}
+	if err := dbConfig.Normalize(); err != nil {
+		log.Fatal(err)
 	}
 
-	// Railway PostgreSQL optimization: disable prepared statements untuk reduce overhead
-	if strings.Contains(dsn, "?") {
-		dsn += "&prefer_simple_protocol=true&connect_timeout=5"
-	} else {
-		dsn += "?sslmode=require&prefer_simple_protocol=true&connect_timeout=5"
-	}
-
-	db, err := gorm.Open(postgres.New(postgres.Config{
-    DSN: dsn,
-    PreferSimpleProtocol: true, // Railway works better dengan simple protocol
-}), &gorm.Config{
-    PrepareStmt: false, // Disable untuk reduce connection overhead
-    SkipDefaultTransaction: true,
-})
-if err != nil {
-    log.Fatal("DB Connection failed: ", err)
-}
-sqlDB, err := db.DB()
-if err != nil {
-    log.Fatal("Failed to get database instance: ", err)
-}
-// Optimize untuk Railway's limited connections
-sqlDB.SetMaxOpenConns(5)
-sqlDB.SetMaxIdleConns(3)
-sqlDB.SetConnMaxLifetime(30 * time.Minute) // Keep connections alive longer
-sqlDB.SetConnMaxIdleTime(5 * time.Minute)
+	db, err := database.Open(dbConfig)
+	if err != nil {
+		log.Fatal("DB Connection failed: ", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("Failed to get database instance: ", err)
+	}
+	log.Println("Database driver:", dbConfig.Driver)
+
+	migrator, err := migrations.New(sqlDB, dbConfig.Driver)
+	if err != nil {
+		log.Fatal("Failed to load migrations: ", err)
+	}
+
+	// go run ./cmd migrate up|down [n]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		RunMigrateCommand(migrator, os.Args[2:])
+		return
+	}
 
+	// Migrasi yang belum jalan dieksekusi saat boot; gagal migrasi = server tidak start
 	log.Println("Migrating database...")
-	// Pastikan struct Admin dan Project ada di models
-	db.AutoMigrate(
-		&models.Project{},
-		&models.Admin{},
-		// DokterBubung Models
-		&models.Medicine{},
-		&models.Prescription{},
-		&models.PrescriptionItem{},
-		&models.Patient{},
-		&models.Log{},
-	)
+	applied, err := migrator.Up()
+	if err != nil {
+		log.Fatal("Database migration failed: ", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
 	log.Println("Database migrated successfully!")
 
-	// Create indexes untuk performance optimization
-	log.Println("Creating database indexes...")
-	db.Exec("CREATE INDEX IF NOT EXISTS idx_prescription_items_prescription_id ON prescription_items(prescription_id)")
-	db.Exec("CREATE INDEX IF NOT EXISTS idx_logs_created_at ON logs(created_at DESC)")
-	db.Exec("CREATE INDEX IF NOT EXISTS idx_prescriptions_status ON prescriptions(status)")
-	db.Exec("CREATE INDEX IF NOT EXISTS idx_patients_created_at ON patients(created_at DESC)")
-	log.Println("Indexes created successfully!")
-
 	// Seed initial data for hospital
 	SeedHospitalData(db)
+	BackfillMedicineBatches(db)
 
 	// 2. WIRING DEPENDENCY INJECTION
 	// Pastikan urutan: Repo -> Service -> Handler
 
+	// Auth tokens
+	tokenRepo := repository.NewTokenRepo(db)
+	tokenService := services.NewTokenService(tokenRepo, services.TokenConfig{
+		Secret:          cfg.Auth.JWTSecret,
+		AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+	})
+	authHandler := handlers.NewAuthHandler(tokenService)
+
 	// Portfolio
 	projectRepo := repository.NewProjectRepo(db)
-	projectService := services.NewProjectService(projectRepo)
-	projectHandler := handlers.NewProjectHandler(projectService)
+	projectService := services.NewProjectService(projectRepo, tokenService)
 
 	// DokterBubung Hospital
-	hospitalRepo := repository.NewHospitalRepo(db)
-	hospitalService := services.NewHospitalService(hospitalRepo)
-	hospitalHandler := handlers.NewHospitalHandler(hospitalService)
+	// Cache bisa dimatikan dengan CACHE_BACKEND=none (mis. saat test)
+	appCache, err := cache.New(cache.Config{
+		Backend:    cfg.Cache.Backend,
+		MaxEntries: cfg.Cache.MaxEntries,
+		RedisURL:   cfg.Cache.RedisURL,
+		KeyPrefix:  "dokterbubung:",
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize cache: ", err)
+	}
+	var notifier *cache.PGNotify
+	// Cache memory per instance; invalidasi disebarkan ke replika lain lewat
+	// PostgreSQL LISTEN/NOTIFY (butuh satu koneksi tambahan, CACHE_NOTIFY=off untuk mematikan)
+	if appCache.Stats().Backend == "memory" && dbConfig.Driver == database.DriverPostgres && cfg.Cache.Notify {
+		notifier = cache.NewPGNotify(appCache, sqlDB, dbConfig.DSN)
+		notifier.Start()
+		appCache = notifier
+	}
+	log.Println("Cache backend:", appCache.Stats().Backend)
+	cacheHandler := handlers.NewCacheHandler(appCache)
+
+	hospitalRepo := repository.NewHospitalRepo(db, appCache, repository.CacheTTL{
+		Medicines:     cfg.Cache.MedicinesTTL,
+		Prescriptions: cfg.Cache.PrescriptionsTTL,
+		Patients:      cfg.Cache.PatientsTTL,
+		Logs:          cfg.Cache.LogsTTL,
+	})
+	eventBus := services.NewEventBus()
+	hospitalService := services.NewHospitalService(hospitalRepo, tokenService, eventBus, services.HospitalConfig{
+		DispenseMinShelfDays: cfg.Hospital.DispenseMinShelfDays,
+		IDFormats:            cfg.Hospital.IDFormats(),
+	})
+
+	// Request divalidasi sebelum masuk service; medicine_id resep dicek ke katalog obat
+	validator := validation.New(hospitalRepo)
+	projectHandler := handlers.NewProjectHandler(projectService, validator)
+	hospitalHandler := handlers.NewHospitalHandler(hospitalService, validator)
+	eventHandler := handlers.NewEventHandler(eventBus)
+
+	// Hubungkan resep lama (sebelum ada patient_id) ke data pasien
+	if report, err := hospitalService.BackfillPrescriptionPatients(); err != nil {
+		log.Println("Prescription patient backfill failed: ", err)
+	} else if report != nil {
+		log.Printf("Prescription patient backfill: %d linked, %d unmatched %v, %d ambiguous %v",
+			report.Linked, len(report.Unmatched), report.Unmatched, len(report.Ambiguous), report.Ambiguous)
+	}
+
+	// Aturan interaksi obat dimuat ulang dari file setiap start
+	rulesFile := cfg.Hospital.InteractionRulesFile
+	if n, err := hospitalService.LoadInteractionRules(rulesFile); err != nil {
+		log.Println("Interaction rules not loaded: ", err)
+	} else {
+		log.Printf("Loaded %d interaction rules from %s", n, rulesFile)
+	}
+
+	// Akun admin rumah sakit pertama dibuat dari env
+	SeedHospitalAdmin(hospitalRepo, hospitalService, cfg.Hospital.AdminUsername, cfg.Hospital.AdminPassword)
+
+	// Evaluasi stok menipis & kedaluwarsa di background
+	alertScheduler := services.NewAlertScheduler(hospitalService, cfg.Hospital.AlertInterval)
+	alertScheduler.Start()
+
+	healthHandler := handlers.NewHealthHandler(sqlDB, migrator)
 
 	// 3. Setup Fiber
-	app := fiber.New()
+	// Semua handler cukup mengembalikan error; status & body JSON-nya diatur ErrorHandler
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.ErrorHandler})
+
+	// Health check platform didaftarkan sebelum logger agar probe tidak membanjiri log
+	app.Get("/healthz", healthHandler.Liveness)
+	app.Get("/readyz", healthHandler.Readiness)
+
 	app.Use(logger.New()) // Tambahan logger agar terlihat request di terminal
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  strings.Join(cfg.Server.CORSAllowOrigins, ","),
+		ExposeHeaders: strings.Join(handlers.PageHeaders, ","),
+	}))
+
+	// Batasi percobaan login per IP untuk memperlambat brute force
+	loginLimiter := limiter.New(limiter.Config{
+		Max:        cfg.Server.LoginRateLimit,
+		Expiration: cfg.Server.LoginRateWindow,
+		LimitReached: func(c *fiber.Ctx) error {
+			return fiber.NewError(fiber.StatusTooManyRequests, "Too many login attempts, try again later")
+		},
+	})
 
 	// 4. Routes
 	api := app.Group("/api")
 
 	// PUBLIC ROUTES
 	api.Get("/projects", projectHandler.GetAll)
-	api.Post("/auth/login", projectHandler.Login)
+	api.Post("/auth/login", loginLimiter, projectHandler.Login)
 
-	// PROTECTED ROUTES (ADMIN)
-	admin := api.Group("/admin")
-
-	// Cek JWT Secret
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET is not set in .env")
+	// Middleware JWT, token yang sudah di-logout/dicabut ditolak
+	unauthorized := func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized Access: Token invalid or missing")
+	}
+	newJWTMiddleware := func(tokenLookup string) fiber.Handler {
+		return jwtware.New(jwtware.Config{
+			SigningKey:  []byte(cfg.Auth.JWTSecret),
+			TokenLookup: tokenLookup,
+			AuthScheme:  "Bearer",
+			ErrorHandler: func(c *fiber.Ctx, err error) error {
+				return unauthorized(c)
+			},
+			SuccessHandler: func(c *fiber.Ctx) error {
+				revoked, err := tokenService.IsRevoked(middleware.TokenID(c), middleware.TokenSubject(c), middleware.TokenIssuedAt(c))
+				if err != nil || revoked {
+					return unauthorized(c)
+				}
+				return c.Next()
+			},
+		})
 	}
+	jwtMiddleware := newJWTMiddleware("header:Authorization")
+	// EventSource di browser tidak bisa mengirim header, jadi stream event
+	// juga menerima token dari query ?access_token=
+	eventsJWTMiddleware := newJWTMiddleware("header:Authorization,query:access_token")
 
-	// Middleware JWT
-	admin.Use(jwtware.New(jwtware.Config{
-		SigningKey: []byte(jwtSecret),
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized Access: Token invalid or missing",
-			})
-		},
-	}))
+	// Refresh & logout berlaku untuk token admin maupun staf
+	api.Post("/auth/refresh", loginLimiter, authHandler.Refresh)
+	api.Post("/auth/logout", jwtMiddleware, authHandler.Logout)
+
+	// PROTECTED ROUTES (ADMIN)
+	admin := api.Group("/admin", jwtMiddleware, middleware.RequireAdmin())
 
 	// Routes yang butuh login
 	admin.Post("/projects", projectHandler.Create)
 	admin.Delete("/projects/:id", projectHandler.Delete)
+	admin.Post("/admins", projectHandler.CreateAdmin)
+	admin.Put("/admins/:id/password", projectHandler.ResetPassword)
+	admin.Put("/password", projectHandler.ChangePassword)
 
 	// DOKTERBUBUNG HOSPITAL ROUTES
-	hospital := api.Group("/hospital")
+	api.Post("/hospital/auth/login", loginLimiter, hospitalHandler.Login)
+	api.Get("/hospital/events", eventsJWTMiddleware, middleware.RequireStaff(), eventHandler.Stream)
+
+	// Semua route rumah sakit lainnya butuh akun staf
+	hospital := api.Group("/hospital", jwtMiddleware, middleware.RequireStaff())
+
+	// Role shortcuts
+	doctor := models.RoleDoctor
+	pharmacist := models.RolePharmacist
+	logistics := models.RoleLogistics
+	frontDesk := models.RoleFrontDesk
+	hospitalAdmin := models.RoleAdmin
+
+	// User routes
+	hospital.Post("/users", middleware.RequireRoles(hospitalAdmin), hospitalHandler.CreateUser)
+	hospital.Get("/cache/stats", middleware.RequireRoles(hospitalAdmin), cacheHandler.Stats)
 
 	// Medicine routes
 	hospital.Get("/medicines", hospitalHandler.GetAllMedicines)
-	hospital.Post("/medicines", hospitalHandler.CreateMedicine)
-	hospital.Put("/medicines/:id/restock", hospitalHandler.RestockMedicine)
+	hospital.Post("/medicines", middleware.RequireRoles(logistics, hospitalAdmin), hospitalHandler.CreateMedicine)
+	hospital.Put("/medicines/:id/restock", middleware.RequireRoles(logistics, hospitalAdmin), hospitalHandler.RestockMedicine)
+	hospital.Put("/medicines/:id/alert-settings", middleware.RequireRoles(logistics, hospitalAdmin), hospitalHandler.UpdateMedicineAlertSettings)
+	hospital.Put("/medicines/:id/ingredients", middleware.RequireRoles(pharmacist, logistics, hospitalAdmin), hospitalHandler.UpdateMedicineIngredients)
+	hospital.Put("/medicines/:id/price", middleware.RequireRoles(logistics, hospitalAdmin), hospitalHandler.UpdateMedicinePrice)
+	hospital.Get("/medicines/:id/prices", middleware.RequireRoles(logistics, pharmacist, hospitalAdmin), hospitalHandler.GetMedicinePrices)
 
 	// Prescription routes
-	hospital.Get("/prescriptions", hospitalHandler.GetAllPrescriptions)
-	hospital.Post("/prescriptions", hospitalHandler.CreatePrescription)
-	hospital.Put("/prescriptions/:id/status", hospitalHandler.UpdatePrescriptionStatus)
+	hospital.Get("/prescriptions", middleware.RequireRoles(doctor, pharmacist, hospitalAdmin), hospitalHandler.GetAllPrescriptions)
+	hospital.Post("/prescriptions", middleware.RequireRoles(doctor, hospitalAdmin), hospitalHandler.CreatePrescription)
+	hospital.Post("/prescriptions/check", middleware.RequireRoles(doctor, pharmacist, hospitalAdmin), hospitalHandler.CheckPrescription)
+	// Role per action dicek di handler
+	hospital.Put("/prescriptions/:id/status", middleware.RequireRoles(doctor, pharmacist, hospitalAdmin), hospitalHandler.UpdatePrescriptionStatus)
 
 	// Patient routes
 	hospital.Get("/patients", hospitalHandler.GetAllPatients)
-	hospital.Post("/patients", hospitalHandler.AddPatient)
-	hospital.Delete("/patients/:id", hospitalHandler.RemovePatient)
+	hospital.Post("/patients", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.AddPatient)
+	hospital.Get("/patients/:id/prescriptions", middleware.RequireRoles(doctor, pharmacist, hospitalAdmin), hospitalHandler.GetPatientPrescriptions)
+	hospital.Put("/patients/:id/allergens", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.UpdatePatientAllergens)
+	hospital.Delete("/patients/:id", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.RemovePatient)
+	hospital.Post("/patients/:id/visits", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.EnqueuePatient)
+
+	// Queue routes
+	hospital.Get("/queue", hospitalHandler.GetQueue)
+	hospital.Post("/queue/call-next", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.CallNextPatient)
+	hospital.Put("/queue/:id/skip", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.SkipVisit)
+	hospital.Put("/queue/:id/recall", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.RecallVisit)
+	hospital.Put("/queue/:id/status", middleware.RequireRoles(frontDesk, doctor, hospitalAdmin), hospitalHandler.UpdateVisitStatus)
 
 	// Log routes
-	hospital.Get("/logs", hospitalHandler.GetAllLogs)
+	hospital.Get("/logs", middleware.RequireRoles(logistics, pharmacist, hospitalAdmin), hospitalHandler.GetAllLogs)
+
+	// Alert routes
+	hospital.Get("/alerts", middleware.RequireRoles(logistics, pharmacist, hospitalAdmin), hospitalHandler.GetAlerts)
+	hospital.Put("/alerts/:id/acknowledge", middleware.RequireRoles(logistics, pharmacist, hospitalAdmin), hospitalHandler.AcknowledgeAlert)
+	hospital.Put("/alerts/:id/resolve", middleware.RequireRoles(logistics, pharmacist, hospitalAdmin), hospitalHandler.ResolveAlert)
 
 	// 5. Start Server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := strconv.Itoa(cfg.Server.Port)
 	log.Println("Server running on port " + port)
-	log.Fatal(app.Listen(":" + port))
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(":" + port)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-listenErr:
+		log.Fatal(err)
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down...", sig)
+	}
+
+	// 6. Graceful shutdown: tolak request baru, selesaikan yang sedang berjalan
+	// (mis. dispensing), lalu hentikan worker dan tutup koneksi database
+	healthHandler.MarkShuttingDown()
+	// Stream SSE tidak pernah selesai sendiri, jadi ditutup lebih dulu
+	eventBus.Close()
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		log.Println("HTTP shutdown: ", err)
+	}
+	alertScheduler.Stop()
+	if notifier != nil {
+		notifier.Close()
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Println("Closing database: ", err)
+	}
+	log.Println("Server stopped")
 }
 
 // SeedHospitalData seeds initial data for hospital management system
@@ -175,11 +345,16 @@ func SeedHospitalData(db *gorm.DB) {
 
 	// Seed Medicines
 	medicines := []models.Medicine{
-		{ID: "OBT001", Name: "Amoxicillin 500mg", Type: "Tablet", Stock: 50, Price: 15000, Expiry: "2026-05-20", Location: "Rak A1"},
-		{ID: "OBT002", Name: "Paracetamol 500mg", Type: "Tablet", Stock: 120, Price: 5000, Expiry: "2027-01-15", Location: "Rak A2"},
-		{ID: "OBT003", Name: "OBH Combi Anak", Type: "Sirup", Stock: 8, Price: 25000, Expiry: "2025-08-10", Location: "Rak B1"},
-		{ID: "OBT004", Name: "Vitamin C 1000mg", Type: "Tablet", Stock: 80, Price: 45000, Expiry: "2025-07-01", Location: "Rak C1"},
-		{ID: "OBT005", Name: "Simvastatin 10mg", Type: "Tablet", Stock: 5, Price: 30000, Expiry: "2025-11-05", Location: "Rak A3"},
+		{ID: "OBT001", Name: "Amoxicillin 500mg", Type: "Tablet", Stock: 50, Price: 15000, Expiry: seedDate("2026-05-20"), Location: "Rak A1",
+			Ingredients: []models.MedicineIngredient{{Ingredient: "Amoxicillin", DrugClass: "Penicillin"}}},
+		{ID: "OBT002", Name: "Paracetamol 500mg", Type: "Tablet", Stock: 120, Price: 5000, Expiry: seedDate("2027-01-15"), Location: "Rak A2",
+			Ingredients: []models.MedicineIngredient{{Ingredient: "Paracetamol", DrugClass: "Analgesic"}}},
+		{ID: "OBT003", Name: "OBH Combi Anak", Type: "Sirup", Stock: 8, Price: 25000, Expiry: seedDate("2025-08-10"), Location: "Rak B1",
+			Ingredients: []models.MedicineIngredient{{Ingredient: "Dextromethorphan", DrugClass: "Antitussive"}}},
+		{ID: "OBT004", Name: "Vitamin C 1000mg", Type: "Tablet", Stock: 80, Price: 45000, Expiry: seedDate("2025-07-01"), Location: "Rak C1",
+			Ingredients: []models.MedicineIngredient{{Ingredient: "Ascorbic Acid", DrugClass: "Vitamin"}}},
+		{ID: "OBT005", Name: "Simvastatin 10mg", Type: "Tablet", Stock: 5, Price: 30000, Expiry: seedDate("2025-11-05"), Location: "Rak A3",
+			Ingredients: []models.MedicineIngredient{{Ingredient: "Simvastatin", DrugClass: "Statin"}}},
 	}
 
 	for _, med := range medicines {
@@ -188,14 +363,150 @@ func SeedHospitalData(db *gorm.DB) {
 
 	// Seed Patients
 	patients := []models.Patient{
-		{ID: "P-001", Name: "Siti Aminah", Dob: "1990-01-01", Status: "Waiting", Allergies: "Seafood"},
-		{ID: "P-002", Name: "Rahmat Hidayat", Dob: "1988-05-12", Status: "Waiting", Allergies: "-"},
-		{ID: "P-003", Name: "Joko Widodo", Dob: "1975-10-20", Status: "Examining", Allergies: "Penicillin"},
+		{ID: "P-001", MedicalRecordNumber: "RM-001", Name: "Siti Aminah", Dob: "1990-01-01", Allergies: "Seafood",
+			Allergens: []models.PatientAllergen{{Allergen: "Seafood"}}},
+		{ID: "P-002", MedicalRecordNumber: "RM-002", Name: "Rahmat Hidayat", Dob: "1988-05-12", Allergies: "-"},
+		{ID: "P-003", MedicalRecordNumber: "RM-003", Name: "Joko Widodo", Dob: "1975-10-20", Allergies: "Penicillin",
+			Allergens: []models.PatientAllergen{{Allergen: "Penicillin"}}},
 	}
 
 	for _, patient := range patients {
 		db.Create(&patient)
 	}
 
+	// Seed antrean hari ini
+	today := models.Today()
+	visits := []models.Visit{
+		{PatientID: "P-001", Status: models.VisitStatusWaiting, Priority: models.PriorityRegular},
+		{PatientID: "P-002", Status: models.VisitStatusWaiting, Priority: models.PriorityRegular},
+		{PatientID: "P-003", Status: models.VisitStatusExamining, Priority: models.PriorityRegular},
+	}
+
+	for i, visit := range visits {
+		visit.Polyclinic = models.DefaultPolyclinic
+		visit.QueueDate = today
+		visit.TicketNumber = i + 1
+		visit.Ticket = fmt.Sprintf("%s-%03d", models.DefaultPolyclinic, i+1)
+		db.Create(&visit)
+	}
+	db.Create(&models.QueueCounter{QueueDate: today, Polyclinic: models.DefaultPolyclinic, LastNumber: len(visits)})
+
 	log.Println("Hospital initial data seeded successfully!")
 }
+
+// RunMigrateCommand menjalankan subcommand `migrate up`, `migrate down [n]`
+// (default 1 langkah) dan `migrate status`
+func RunMigrateCommand(migrator *migrations.Migrator, args []string) {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("up   %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal("migrate down: steps must be a positive number")
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("down %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Nothing to roll back")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", st.Version, st.Name, applied)
+		}
+	default:
+		log.Fatalf("unknown migrate command %q (use up, down [n] or status)", action)
+	}
+}
+
+func seedDate(s string) models.Date {
+	d, err := models.ParseDate(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return d
+}
+
+// BackfillMedicineBatches membuat batch pembuka untuk obat yang punya stok tapi
+// belum punya batch (data sebelum stok dicatat per batch)
+func BackfillMedicineBatches(db *gorm.DB) {
+	var medicines []models.Medicine
+	err := db.Where("stock > 0 AND NOT EXISTS (SELECT 1 FROM medicine_batches b WHERE b.medicine_id = medicines.id)").
+		Find(&medicines).Error
+	if err != nil {
+		log.Println("Failed to check medicine batches: ", err)
+		return
+	}
+
+	for _, med := range medicines {
+		db.Create(&models.MedicineBatch{
+			MedicineID:   med.ID,
+			LotNumber:    "LEGACY",
+			Expiry:       med.Expiry,
+			Qty:          med.Stock,
+			InitialQty:   med.Stock,
+			ReceivedDate: models.Date{Time: med.CreatedAt},
+		})
+	}
+	if len(medicines) > 0 {
+		log.Printf("Created opening batches for %d medicines", len(medicines))
+	}
+}
+
+// SeedHospitalAdmin membuat akun admin rumah sakit pertama dari
+// HOSPITAL_ADMIN_USERNAME dan HOSPITAL_ADMIN_PASSWORD jika belum ada akun sama sekali.
+// Akun staf lainnya dibuat oleh admin lewat POST /api/hospital/users.
+func SeedHospitalAdmin(repo repository.HospitalRepository, service services.HospitalService, username, password string) {
+	count, err := repo.CountUsers()
+	if err != nil || count > 0 {
+		return
+	}
+
+	if username == "" || password == "" {
+		log.Println("No hospital users yet. Set HOSPITAL_ADMIN_USERNAME and HOSPITAL_ADMIN_PASSWORD to create the first admin")
+		return
+	}
+
+	_, err = service.CreateUser(&models.CreateHospitalUserRequest{
+		Username: username,
+		Name:     "Administrator",
+		Role:     models.RoleAdmin,
+		Password: password,
+	})
+	if err != nil {
+		log.Println("Failed to seed hospital admin: ", err)
+		return
+	}
+	log.Println("Hospital admin account created: " + username)
+}
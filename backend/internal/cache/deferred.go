@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Deferred membungkus cache untuk dipakai di dalam transaksi database: baca
+// selalu miss (data di transaksi belum tentu di-commit), tulis diabaikan, dan
+// invalidasi ditahan sampai Flush dipanggil setelah commit. Tanpa ini, request
+// lain bisa mengisi ulang cache dengan data lama sebelum transaksi selesai.
+type Deferred struct {
+	target Cache
+
+	mu       sync.Mutex
+	keys     []string
+	prefixes []string
+}
+
+func NewDeferred(target Cache) *Deferred {
+	return &Deferred{target: target}
+}
+
+func (d *Deferred) Get(key string) ([]byte, bool) {
+	return nil, false
+}
+
+func (d *Deferred) Set(key string, value []byte, ttl time.Duration) {}
+
+func (d *Deferred) Delete(keys ...string) {
+	d.mu.Lock()
+	d.keys = append(d.keys, keys...)
+	d.mu.Unlock()
+}
+
+func (d *Deferred) DeletePrefix(prefix string) {
+	d.mu.Lock()
+	d.prefixes = append(d.prefixes, prefix)
+	d.mu.Unlock()
+}
+
+func (d *Deferred) Stats() Stats {
+	return d.target.Stats()
+}
+
+// Flush menjalankan semua invalidasi yang ditahan ke cache asli
+func (d *Deferred) Flush() {
+	d.mu.Lock()
+	keys, prefixes := d.keys, d.prefixes
+	d.keys, d.prefixes = nil, nil
+	d.mu.Unlock()
+
+	if len(keys) > 0 {
+		d.target.Delete(keys...)
+	}
+	seen := map[string]bool{}
+	for _, prefix := range prefixes {
+		if !seen[prefix] {
+			seen[prefix] = true
+			d.target.DeletePrefix(prefix)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries dipakai jika MaxEntries tidak diisi
+const DefaultMaxEntries = 1000
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// memoryCache adalah cache LRU in-process dengan TTL per entry
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // depan = paling baru dipakai
+	items      map[string]*list.Element
+	counters
+}
+
+func NewMemory(maxEntries int) Cache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &memoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      map[string]*list.Element{},
+	}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	elem, ok := c.items[key]
+	if ok && time.Now().After(elem.Value.(*memoryEntry).expiresAt) {
+		c.remove(elem)
+		ok = false
+	}
+	var value []byte
+	if ok {
+		c.order.MoveToFront(elem)
+		value = elem.Value.(*memoryEntry).value
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.miss(key)
+		return nil, false
+	}
+	c.hit(key)
+	return value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.sets.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *memoryCache) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+			c.deletes.Add(1)
+		}
+	}
+}
+
+func (c *memoryCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+			c.deletes.Add(1)
+		}
+	}
+}
+
+func (c *memoryCache) Stats() Stats {
+	c.mu.Lock()
+	entries := len(c.items)
+	c.mu.Unlock()
+	return c.snapshot("memory", entries)
+}
+
+// remove harus dipanggil dengan c.mu terkunci
+func (c *memoryCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*memoryEntry).key)
+}
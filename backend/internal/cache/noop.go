@@ -0,0 +1,27 @@
+package cache
+
+import "time"
+
+// noopCache selalu miss; dipakai untuk mematikan cache (CACHE_BACKEND=none, test)
+type noopCache struct {
+	counters
+}
+
+func Noop() Cache {
+	return &noopCache{}
+}
+
+func (c *noopCache) Get(key string) ([]byte, bool) {
+	c.miss(key)
+	return nil, false
+}
+
+func (c *noopCache) Set(key string, value []byte, ttl time.Duration) {}
+
+func (c *noopCache) Delete(keys ...string) {}
+
+func (c *noopCache) DeletePrefix(prefix string) {}
+
+func (c *noopCache) Stats() Stats {
+	return c.snapshot("none", 0)
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InvalidationChannel adalah channel LISTEN/NOTIFY PostgreSQL untuk invalidasi cache
+const InvalidationChannel = "cache_invalidation"
+
+const (
+	notifyTimeout    = 2 * time.Second
+	minReconnectWait = time.Second
+	maxReconnectWait = 30 * time.Second
+)
+
+type invalidation struct {
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// PGNotify membungkus cache lokal (memory) agar setiap invalidasi juga dikirim
+// ke replika lain lewat PostgreSQL NOTIFY, dan invalidasi dari replika lain
+// diterapkan ke cache lokal. Tidak perlu untuk backend Redis yang sudah dipakai bersama.
+type PGNotify struct {
+	Cache
+
+	db     *sql.DB
+	dsn    string
+	origin string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPGNotify membuat wrapper invalidasi; panggil Start untuk mulai LISTEN.
+// db dipakai untuk NOTIFY, dsn untuk koneksi LISTEN tersendiri (di luar pool).
+func NewPGNotify(local Cache, db *sql.DB, dsn string) *PGNotify {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &PGNotify{Cache: local, db: db, dsn: dsn, origin: hex.EncodeToString(b)}
+}
+
+func (n *PGNotify) Delete(keys ...string) {
+	n.Cache.Delete(keys...)
+	n.publish(invalidation{Keys: keys})
+}
+
+func (n *PGNotify) DeletePrefix(prefix string) {
+	n.Cache.DeletePrefix(prefix)
+	n.publish(invalidation{Prefixes: []string{prefix}})
+}
+
+func (n *PGNotify) Stats() Stats {
+	stats := n.Cache.Stats()
+	stats.Backend += "+pgnotify"
+	return stats
+}
+
+func (n *PGNotify) publish(msg invalidation) {
+	msg.Origin = n.origin
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if _, err := n.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", InvalidationChannel, string(payload)); err != nil {
+		log.Println("Cache invalidation notify failed: ", err)
+	}
+}
+
+// Start menjalankan listener di background sampai Close dipanggil
+func (n *PGNotify) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	n.done = make(chan struct{})
+	go n.run(ctx)
+}
+
+// Close menghentikan listener dan menunggu koneksinya ditutup
+func (n *PGNotify) Close() {
+	if n.cancel == nil {
+		return
+	}
+	n.cancel()
+	<-n.done
+}
+
+func (n *PGNotify) run(ctx context.Context) {
+	defer close(n.done)
+
+	wait := minReconnectWait
+	for {
+		listened, err := n.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// Backoff hanya untuk kegagalan beruntun; koneksi yang sempat LISTEN
+		// lalu putus dicoba lagi dari jeda awal
+		if listened {
+			wait = minReconnectWait
+		}
+		log.Printf("Cache invalidation listener disconnected: %v (retrying in %s)", err, wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxReconnectWait)
+	}
+}
+
+// listen memegang satu koneksi LISTEN sampai koneksi putus atau ctx dibatalkan.
+// listened bernilai true jika LISTEN sempat berhasil sebelum error.
+func (n *PGNotify) listen(ctx context.Context) (listened bool, err error) {
+	conn, err := pgx.Connect(ctx, n.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+InvalidationChannel); err != nil {
+		return false, err
+	}
+
+	// Selama terputus, notifikasi dari replika lain bisa terlewat
+	n.Cache.DeletePrefix("")
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		var msg invalidation
+		if err := json.Unmarshal([]byte(notification.Payload), &msg); err != nil || msg.Origin == n.origin {
+			continue
+		}
+		if len(msg.Keys) > 0 {
+			n.Cache.Delete(msg.Keys...)
+		}
+		for _, prefix := range msg.Prefixes {
+			n.Cache.DeletePrefix(prefix)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Batas waktu operasi Redis; cache yang lambat lebih buruk daripada miss
+const redisTimeout = 200 * time.Millisecond
+
+// redisCache menyimpan entry di Redis (atau server kompatibel seperti Valkey/KeyDB)
+// sehingga cache dan invalidasinya berlaku untuk semua instance
+type redisCache struct {
+	client *redis.Client
+	prefix string
+	counters
+}
+
+func NewRedis(url string, keyPrefix string) (Cache, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisCache{client: client, prefix: keyPrefix}, nil
+}
+
+func (c *redisCache) Get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			c.errors.Add(1)
+		}
+		c.miss(key)
+		return nil, false
+	}
+	c.hit(key)
+	return value, true
+}
+
+func (c *redisCache) Set(key string, value []byte, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	c.sets.Add(1)
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		c.errors.Add(1)
+	}
+}
+
+func (c *redisCache) Delete(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	full := make([]string, len(keys))
+	for i, key := range keys {
+		full[i] = c.prefix + key
+	}
+	deleted, err := c.client.Del(ctx, full...).Result()
+	if err != nil {
+		c.errors.Add(1)
+		return
+	}
+	c.deletes.Add(uint64(deleted))
+}
+
+func (c *redisCache) DeletePrefix(prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*redisTimeout)
+	defer cancel()
+
+	// SCAN bertahap agar tidak memblokir Redis seperti KEYS
+	iter := c.client.Scan(ctx, 0, c.prefix+prefix+"*", 100).Iterator()
+	var batch []string
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == 100 {
+			c.del(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		c.errors.Add(1)
+	}
+	c.del(ctx, batch)
+}
+
+func (c *redisCache) del(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	deleted, err := c.client.Del(ctx, keys...).Result()
+	if err != nil {
+		c.errors.Add(1)
+		return
+	}
+	c.deletes.Add(uint64(deleted))
+}
+
+func (c *redisCache) Stats() Stats {
+	// Jumlah entry tidak dihitung karena butuh SCAN seluruh keyspace
+	return c.snapshot("redis", -1)
+}
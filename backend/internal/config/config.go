@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"backend/internal/cache"
+	"backend/internal/database"
+	"backend/internal/idformat"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile dibaca jika ada dan CONFIG_FILE tidak diisi
+const DefaultFile = "config.yaml"
+
+// Config adalah seluruh konfigurasi aplikasi. Urutan sumber: default di bawah,
+// lalu file YAML, lalu environment (termasuk .env) yang selalu menang.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Hospital HospitalConfig `yaml:"hospital"`
+}
+
+type ServerConfig struct {
+	Port int `yaml:"port" env:"PORT"`
+	// CORSAllowOrigins: daftar origin frontend, "*" untuk mengizinkan semua
+	CORSAllowOrigins []string      `yaml:"cors_allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	LoginRateLimit   int           `yaml:"login_rate_limit" env:"LOGIN_RATE_LIMIT"` // Percobaan login per IP per window
+	LoginRateWindow  time.Duration `yaml:"login_rate_window" env:"LOGIN_RATE_WINDOW"`
+	// ShutdownTimeout: batas menunggu request yang sedang berjalan saat SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver" env:"DB_DRIVER"`
+	URL             string        `yaml:"url" env:"DATABASE_URL"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+type CacheConfig struct {
+	Backend    string `yaml:"backend" env:"CACHE_BACKEND"`
+	MaxEntries int    `yaml:"max_entries" env:"CACHE_MAX_ENTRIES"`
+	RedisURL   string `yaml:"redis_url" env:"REDIS_URL"`
+	// Notify menyebarkan invalidasi cache memory ke replika lain lewat PostgreSQL NOTIFY
+	Notify           bool          `yaml:"notify" env:"CACHE_NOTIFY"`
+	MedicinesTTL     time.Duration `yaml:"medicines_ttl" env:"CACHE_TTL_MEDICINES"`
+	PrescriptionsTTL time.Duration `yaml:"prescriptions_ttl" env:"CACHE_TTL_PRESCRIPTIONS"`
+	PatientsTTL      time.Duration `yaml:"patients_ttl" env:"CACHE_TTL_PATIENTS"`
+	LogsTTL          time.Duration `yaml:"logs_ttl" env:"CACHE_TTL_LOGS"`
+}
+
+type HospitalConfig struct {
+	DispenseMinShelfDays int           `yaml:"dispense_min_shelf_days" env:"DISPENSE_MIN_SHELF_DAYS"`
+	AlertInterval        time.Duration `yaml:"alert_interval" env:"ALERT_INTERVAL"`
+	InteractionRulesFile string        `yaml:"interaction_rules_file" env:"INTERACTION_RULES_FILE"`
+	// Format ID, mis. RSP-{date:200601}-{seq:5}; kosong = default
+	PrescriptionIDFormat string `yaml:"prescription_id_format" env:"ID_FORMAT_PRESCRIPTION"`
+	MedicineIDFormat     string `yaml:"medicine_id_format" env:"ID_FORMAT_MEDICINE"`
+	PatientIDFormat      string `yaml:"patient_id_format" env:"ID_FORMAT_PATIENT"`
+	// Akun admin pertama, hanya dipakai saat belum ada akun staf sama sekali
+	AdminUsername string `yaml:"admin_username" env:"HOSPITAL_ADMIN_USERNAME"`
+	AdminPassword string `yaml:"admin_password" env:"HOSPITAL_ADMIN_PASSWORD"`
+}
+
+// Default mengembalikan konfigurasi bawaan
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:             8080,
+			CORSAllowOrigins: []string{"http://localhost:3000"},
+			LoginRateLimit:   10,
+			LoginRateWindow:  time.Minute,
+			ShutdownTimeout:  15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          database.DriverPostgres,
+			MaxOpenConns:    5,
+			MaxIdleConns:    3,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+		},
+		Cache: CacheConfig{
+			Backend:          "memory",
+			MaxEntries:       cache.DefaultMaxEntries,
+			Notify:           true,
+			MedicinesTTL:     5 * time.Minute,
+			PrescriptionsTTL: 2 * time.Minute,
+			PatientsTTL:      1 * time.Minute,
+			LogsTTL:          30 * time.Second,
+		},
+		Hospital: HospitalConfig{
+			AlertInterval:        time.Hour,
+			InteractionRulesFile: "data/interaction_rules.json",
+		},
+	}
+}
+
+// ValidationError berisi semua key yang hilang atau tidak valid sekaligus,
+// supaya tidak perlu restart berkali-kali untuk menemukannya satu per satu
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load membaca .env, file YAML (CONFIG_FILE atau config.yaml jika ada) dan
+// environment di atas nilai default, lalu memvalidasi hasilnya
+func Load() (*Config, error) {
+	godotenv.Load()
+
+	cfg := Default()
+	var problems []string
+
+	file, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		file = DefaultFile
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				problems = append(problems, fmt.Sprintf("CONFIG_FILE %s: %v", file, err))
+			}
+		case !errors.Is(err, os.ErrNotExist) || explicit:
+			problems = append(problems, fmt.Sprintf("CONFIG_FILE %s: %v", file, err))
+		}
+	}
+
+	problems = append(problems, applyEnv(&cfg)...)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(key, format string, args ...interface{}) {
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("PORT", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if len(c.Server.CORSAllowOrigins) == 0 {
+		add("CORS_ALLOW_ORIGINS", "is required (comma-separated origins, or * to allow all)")
+	}
+	if c.Server.LoginRateLimit < 1 {
+		add("LOGIN_RATE_LIMIT", "must be at least 1")
+	}
+	if c.Server.LoginRateWindow <= 0 {
+		add("LOGIN_RATE_WINDOW", "must be a positive duration")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("SHUTDOWN_TIMEOUT", "must be a positive duration")
+	}
+
+	switch strings.ToLower(c.Database.Driver) {
+	case database.DriverPostgres, "postgresql":
+		if c.Database.URL == "" {
+			add("DATABASE_URL", "is required for the postgres driver")
+		}
+	case database.DriverSQLite, "sqlite3":
+	default:
+		add("DB_DRIVER", "must be postgres or sqlite, got %q", c.Database.Driver)
+	}
+	if c.Database.MaxOpenConns < 1 {
+		add("DB_MAX_OPEN_CONNS", "must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("DB_MAX_IDLE_CONNS", "must be between 0 and DB_MAX_OPEN_CONNS (%d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		add("DB_CONN_MAX_LIFETIME", "must not be negative")
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		add("DB_CONN_MAX_IDLE_TIME", "must not be negative")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		add("JWT_SECRET", "is required")
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		add("ACCESS_TOKEN_TTL", "must be a positive duration")
+	}
+	if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+		add("REFRESH_TOKEN_TTL", "must be longer than ACCESS_TOKEN_TTL")
+	}
+
+	switch strings.ToLower(c.Cache.Backend) {
+	case "", "memory", "none", "off", "disabled":
+	case "redis":
+		if c.Cache.RedisURL == "" {
+			add("REDIS_URL", "is required for the redis cache backend")
+		}
+	default:
+		add("CACHE_BACKEND", "must be memory, redis or none, got %q", c.Cache.Backend)
+	}
+	if c.Cache.MaxEntries < 1 {
+		add("CACHE_MAX_ENTRIES", "must be at least 1")
+	}
+	for _, ttl := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"CACHE_TTL_MEDICINES", c.Cache.MedicinesTTL},
+		{"CACHE_TTL_PRESCRIPTIONS", c.Cache.PrescriptionsTTL},
+		{"CACHE_TTL_PATIENTS", c.Cache.PatientsTTL},
+		{"CACHE_TTL_LOGS", c.Cache.LogsTTL},
+	} {
+		if ttl.value <= 0 {
+			add(ttl.key, "must be a positive duration")
+		}
+	}
+
+	if c.Hospital.DispenseMinShelfDays < 0 {
+		add("DISPENSE_MIN_SHELF_DAYS", "must not be negative")
+	}
+	if c.Hospital.AlertInterval < time.Minute {
+		add("ALERT_INTERVAL", "must be at least 1m")
+	}
+	for _, format := range []struct {
+		key   string
+		value string
+	}{
+		{"ID_FORMAT_PRESCRIPTION", c.Hospital.PrescriptionIDFormat},
+		{"ID_FORMAT_MEDICINE", c.Hospital.MedicineIDFormat},
+		{"ID_FORMAT_PATIENT", c.Hospital.PatientIDFormat},
+	} {
+		if format.value == "" {
+			continue
+		}
+		if err := idformat.Validate(format.value); err != nil {
+			add(format.key, "%v", err)
+		}
+	}
+	if (c.Hospital.AdminUsername == "") != (c.Hospital.AdminPassword == "") {
+		add("HOSPITAL_ADMIN_PASSWORD", "HOSPITAL_ADMIN_USERNAME and HOSPITAL_ADMIN_PASSWORD must be set together")
+	}
+
+	return problems
+}
+
+// IDFormats mengembalikan format ID yang diisi, dengan key jenis ID dari idformat
+func (h HospitalConfig) IDFormats() map[string]string {
+	formats := map[string]string{}
+	for kind, format := range map[string]string{
+		idformat.Prescription: h.PrescriptionIDFormat,
+		idformat.Medicine:     h.MedicineIDFormat,
+		idformat.Patient:      h.PatientIDFormat,
+	} {
+		if format != "" {
+			formats[kind] = format
+		}
+	}
+	return formats
+}
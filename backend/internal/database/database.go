@@ -0,0 +1,141 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Driver database yang didukung; nilainya sama dengan nama folder migrasi
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DefaultSQLitePath dipakai jika DB_DRIVER=sqlite tanpa DATABASE_URL
+const DefaultSQLitePath = "dokterbubung.db"
+
+// Config memilih driver dan koneksi database
+type Config struct {
+	Driver          string // postgres (default), sqlite
+	DSN             string // URL PostgreSQL atau path file SQLite (":memory:" untuk sementara)
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // 0 = koneksi tidak pernah ditutup karena umur
+	ConnMaxIdleTime time.Duration
+}
+
+// Normalize mengisi default dan melengkapi DSN sesuai driver. Panggil sebelum Open;
+// DSN hasilnya juga dipakai koneksi LISTEN di luar pool.
+func (c *Config) Normalize() error {
+	c.Driver = strings.ToLower(strings.TrimSpace(c.Driver))
+	switch c.Driver {
+	case "", DriverPostgres, "postgresql":
+		c.Driver = DriverPostgres
+		if c.DSN == "" {
+			return fmt.Errorf("DATABASE_URL is not set")
+		}
+		c.DSN = postgresDSN(c.DSN)
+	case DriverSQLite, "sqlite3":
+		c.Driver = DriverSQLite
+		if c.DSN == "" {
+			c.DSN = DefaultSQLitePath
+		}
+		c.DSN = sqliteDSN(c.DSN)
+	default:
+		return fmt.Errorf("unknown database driver %q (use postgres or sqlite)", c.Driver)
+	}
+
+	if c.MaxOpenConns <= 0 {
+		c.MaxOpenConns = 5
+	}
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = 3
+	}
+	// Setiap koneksi ke ":memory:" adalah database terpisah
+	if c.Driver == DriverSQLite && strings.Contains(c.DSN, ":memory:") {
+		c.MaxOpenConns, c.MaxIdleConns = 1, 1
+	}
+	return nil
+}
+
+// Open membuka koneksi GORM sesuai Config yang sudah di-Normalize
+func Open(c Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch c.Driver {
+	case DriverPostgres:
+		dialector = postgres.New(postgres.Config{
+			DSN:                  c.DSN,
+			PreferSimpleProtocol: true, // Railway works better dengan simple protocol
+		})
+	case DriverSQLite:
+		dialector = sqlite.Open(c.DSN)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", c.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		PrepareStmt:            false, // Disable untuk reduce connection overhead
+		SkipDefaultTransaction: true,
+		TranslateError:         true, // unique violation jadi gorm.ErrDuplicatedKey di kedua driver
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// Railway membatasi jumlah koneksi, jadi pool sengaja kecil (lihat config)
+	sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	return db, nil
+}
+
+// postgresDSN menambahkan parameter koneksi ke DSN berbentuk URL yang belum
+// menyetelnya. sslmode=require hanya dipaksa untuk host selain localhost.
+// DSN berbentuk key=value dipakai apa adanya.
+func postgresDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return dsn
+	}
+
+	query := u.Query()
+	setDefault := func(key, value string) {
+		if query.Get(key) == "" {
+			query.Set(key, value)
+		}
+	}
+	// Railway PostgreSQL optimization: disable prepared statements untuk reduce overhead
+	setDefault("prefer_simple_protocol", "true")
+	setDefault("connect_timeout", "5")
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1", "":
+	default:
+		setDefault("sslmode", "require")
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// sqliteDSN menyalakan foreign key, WAL dan busy timeout. Transaksi memakai
+// BEGIN IMMEDIATE agar penulis antre, bukan gagal SQLITE_BUSY di tengah transaksi.
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "_pragma=") {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthHandler struct {
+	tokens services.TokenService
+}
+
+func NewAuthHandler(tokens services.TokenService) *AuthHandler {
+	return &AuthHandler{tokens}
+}
+
+// Refresh menukar refresh token dengan access token + refresh token baru
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return services.Validation("refresh_token_required", "refresh_token is required")
+	}
+
+	tokens, err := h.tokens.Refresh(req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// Logout mencabut access token yang dipakai dan refresh token (jika dikirim)
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	// Body opsional
+	var req models.RefreshTokenRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errInvalidBody
+		}
+	}
+
+	if err := h.tokens.Revoke(middleware.TokenID(c), middleware.TokenExpiry(c), req.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "Logged out successfully"})
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"backend/internal/cache"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CacheHandler struct {
+	cache cache.Cache
+}
+
+func NewCacheHandler(c cache.Cache) *CacheHandler {
+	return &CacheHandler{c}
+}
+
+// Stats mengembalikan metrik hit/miss cache untuk monitoring
+func (h *CacheHandler) Stats(c *fiber.Ctx) error {
+	return c.JSON(h.cache.Stats())
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ErrorResponse adalah body semua response error. Code stabil dan bisa dipakai
+// client untuk bercabang; Error hanya untuk ditampilkan.
+type ErrorResponse struct {
+	Error   string                 `json:"error"`
+	Code    string                 `json:"code"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+var kindStatus = map[services.Kind]int{
+	services.KindValidation:      fiber.StatusBadRequest,
+	services.KindUnauthorized:    fiber.StatusUnauthorized,
+	services.KindForbidden:       fiber.StatusForbidden,
+	services.KindNotFound:        fiber.StatusNotFound,
+	services.KindConflict:        fiber.StatusConflict,
+	services.KindUnprocessable:   fiber.StatusUnprocessableEntity,
+	services.KindTooManyRequests: fiber.StatusTooManyRequests,
+}
+
+// Error dari sisi handler (body/query/param tidak bisa dibaca)
+var (
+	errInvalidBody  = services.Validation("invalid_body", "Invalid request body")
+	errInvalidQuery = services.Validation("invalid_query", "Invalid query parameters")
+)
+
+func invalidParam(name string) error {
+	return services.Validation("invalid_param", "Invalid %s", name)
+}
+
+// ErrorHandler dipasang di fiber.Config. Handler cukup mengembalikan error;
+// di sini error domain dipetakan ke status HTTP dan body ErrorResponse.
+// Error yang tidak dikenal dicatat di log dan dijawab 500 tanpa detail internal.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	status, body := errorResponse(err)
+	if status >= fiber.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+	}
+	return c.Status(status).JSON(body)
+}
+
+func errorResponse(err error) (int, ErrorResponse) {
+	if de, ok := services.AsDomainError(err); ok {
+		status, known := kindStatus[de.Kind()]
+		if !known {
+			status = fiber.StatusInternalServerError
+		}
+		// Pesan lengkap (termasuk konteks dari fmt.Errorf) kecuali de hasil terjemahan error repository
+		msg := err.Error()
+		if !errors.Is(err, de) {
+			msg = de.Error()
+		}
+		return status, ErrorResponse{Error: msg, Code: de.Code(), Details: de.Details()}
+	}
+
+	// Error bawaan Fiber/middleware (404 route, 405, body terlalu besar, fiber.NewError)
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code, ErrorResponse{Error: fe.Message, Code: statusCode(fe.Code)}
+	}
+
+	return fiber.StatusInternalServerError, ErrorResponse{Error: "Internal server error", Code: "internal_error"}
+}
+
+// statusCode mengubah status HTTP menjadi kode snake_case, mis. 404 -> "not_found"
+func statusCode(status int) string {
+	msg := strings.ToLower(utils.StatusMessage(status))
+	if msg == "" {
+		return "error"
+	}
+	return strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(msg)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Komentar kosong berkala agar proxy tidak memutus koneksi dan klien yang
+// sudah pergi cepat terdeteksi
+const sseHeartbeat = 15 * time.Second
+
+// topicRoles mengikuti role route REST yang membaca data yang sama; nil berarti
+// semua staf. Event resep berisi data pasien (PHI) sehingga dibatasi.
+var topicRoles = map[string][]string{
+	services.TopicMedicines:     nil,
+	services.TopicPrescriptions: {models.RoleDoctor, models.RolePharmacist, models.RoleAdmin},
+	services.TopicPatients:      nil,
+	services.TopicQueue:         nil,
+	services.TopicAlerts:        {models.RoleLogistics, models.RolePharmacist, models.RoleAdmin},
+}
+
+// allTopics menentukan urutan topik saat klien tidak memfilter
+var allTopics = []string{
+	services.TopicMedicines,
+	services.TopicPrescriptions,
+	services.TopicPatients,
+	services.TopicQueue,
+	services.TopicAlerts,
+}
+
+func canSubscribe(c *fiber.Ctx, topic string) bool {
+	roles := topicRoles[topic]
+	return roles == nil || middleware.HasRole(c, roles...)
+}
+
+// subscriptionTopics membaca ?topics=. Topik di luar hak role ditolak dengan 403.
+func subscriptionTopics(c *fiber.Ctx) ([]string, error) {
+	var topics []string
+	for _, topic := range strings.Split(c.Query("topics"), ",") {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		if _, known := topicRoles[topic]; !known {
+			return nil, services.Validation("unknown_topic", "Unknown topic: %s", topic)
+		}
+		if !canSubscribe(c, topic) {
+			return nil, fiber.NewError(fiber.StatusForbidden, "Forbidden: your role cannot subscribe to "+topic)
+		}
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		for _, topic := range allTopics {
+			if canSubscribe(c, topic) {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics, nil
+}
+
+type EventHandler struct {
+	events services.EventBus
+}
+
+func NewEventHandler(events services.EventBus) *EventHandler {
+	return &EventHandler{events}
+}
+
+// Stream mengirim event perubahan data sebagai Server-Sent Events.
+// Filter topik lewat ?topics=queue,prescriptions (kosong = semua topik yang
+// boleh dibaca role staf).
+func (h *EventHandler) Stream(c *fiber.Ctx) error {
+	topics, err := subscriptionTopics(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	events, unsubscribe := h.events.Subscribe(topics)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			// Flush gagal berarti klien sudah menutup koneksi
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"backend/internal/service"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestSubscriptionTopicsAuthorizesByRole(t *testing.T) {
+	tests := []struct {
+		role   string
+		query  string
+		topics []string
+		status int
+	}{
+		{models.RoleLogistics, "prescriptions", nil, fiber.StatusForbidden},
+		{models.RoleFrontDesk, "queue,prescriptions", nil, fiber.StatusForbidden},
+		{models.RoleFrontDesk, "alerts", nil, fiber.StatusForbidden},
+		{models.RoleFrontDesk, "nope", nil, fiber.StatusBadRequest},
+		{models.RoleDoctor, "prescriptions", []string{services.TopicPrescriptions}, fiber.StatusOK},
+		{models.RoleFrontDesk, "", []string{services.TopicMedicines, services.TopicPatients, services.TopicQueue}, fiber.StatusOK},
+		{models.RolePharmacist, "", allTopics, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+"/"+tt.query, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			var topics []string
+			app.Get("/events", func(c *fiber.Ctx) error {
+				c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"role": tt.role}})
+				var err error
+				topics, err = subscriptionTopics(c)
+				return err
+			})
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/events?topics="+tt.query, nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if !reflect.DeepEqual(topics, tt.topics) {
+				t.Errorf("topics = %v, want %v", topics, tt.topics)
+			}
+		})
+	}
+}
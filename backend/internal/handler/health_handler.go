@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"backend/internal/migrations"
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Batas waktu cek database saat readiness probe
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db           *sql.DB
+	migrator     *migrations.Migrator
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(db *sql.DB, migrator *migrations.Migrator) *HealthHandler {
+	return &HealthHandler{db: db, migrator: migrator}
+}
+
+// MarkShuttingDown membuat /readyz gagal agar load balancer berhenti mengirim
+// request baru selama server menyelesaikan request yang sedang berjalan
+func (h *HealthHandler) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness: proses hidup dan bisa melayani HTTP, tanpa menyentuh database
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// Readiness: database bisa di-ping dan semua migrasi sudah dijalankan
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	checks := fiber.Map{}
+	ready := true
+
+	if h.shuttingDown.Load() {
+		checks["server"] = "shutting down"
+		ready = false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	} else {
+		checks["database"] = "ok"
+		pending, err := h.migrator.Pending(ctx)
+		switch {
+		case err != nil:
+			checks["migrations"] = err.Error()
+			ready = false
+		case len(pending) > 0:
+			checks["migrations"] = fiber.Map{"pending": len(pending)}
+			ready = false
+		default:
+			checks["migrations"] = "ok"
+		}
+	}
+
+	if !ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "unavailable", "checks": checks})
+	}
+	return c.JSON(fiber.Map{"status": "ok", "checks": checks})
+}
@@ -1,38 +1,50 @@
 package handlers
 
 import (
+	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/service"
+	"backend/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type HospitalHandler struct {
-	service services.HospitalService
+	service   services.HospitalService
+	validator *validation.Validator
 }
 
-func NewHospitalHandler(service services.HospitalService) *HospitalHandler {
-	return &HospitalHandler{service: service}
+func NewHospitalHandler(service services.HospitalService, validator *validation.Validator) *HospitalHandler {
+	return &HospitalHandler{service: service, validator: validator}
 }
 
 // ============ MEDICINE HANDLERS ============
 
 func (h *HospitalHandler) GetAllMedicines(c *fiber.Ctx) error {
-	medicines, err := h.service.GetAllMedicines()
+	var filter models.MedicineFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return errInvalidQuery
+	}
+	filter.Normalize()
+
+	medicines, total, err := h.service.GetAllMedicines(filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+
+	setPageHeaders(c, filter.ListQuery, total)
 	return c.JSON(medicines)
 }
 
 func (h *HospitalHandler) CreateMedicine(c *fiber.Ctx) error {
-	var medicine models.Medicine
-	if err := c.BodyParser(&medicine); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	var req models.CreateMedicineRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
 	}
 
-	if err := h.service.CreateMedicine(&medicine); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	medicine, err := h.service.CreateMedicine(&req)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(201).JSON(medicine)
@@ -42,56 +54,155 @@ func (h *HospitalHandler) RestockMedicine(c *fiber.Ctx) error {
 	id := c.Params("id")
 	
 	var req models.RestockRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
 	}
 
-	if err := h.service.RestockMedicine(id, req.Amount, "Staff Gudang"); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.RestockMedicine(id, &req, middleware.UserName(c)); err != nil {
+		return err
 	}
 
 	return c.JSON(fiber.Map{"message": "Stock updated successfully"})
 }
 
+func (h *HospitalHandler) UpdateMedicineAlertSettings(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.UpdateAlertSettingsRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	medicine, err := h.service.UpdateMedicineAlertSettings(id, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(medicine)
+}
+
+func (h *HospitalHandler) UpdateMedicineIngredients(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.UpdateIngredientsRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	medicine, err := h.service.UpdateMedicineIngredients(id, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(medicine)
+}
+
+func (h *HospitalHandler) UpdateMedicinePrice(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.UpdatePriceRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	medicine, err := h.service.UpdateMedicinePrice(id, &req, middleware.UserName(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(medicine)
+}
+
+// GetMedicinePrices mengembalikan riwayat harga obat, terbaru dulu
+func (h *HospitalHandler) GetMedicinePrices(c *fiber.Ctx) error {
+	prices, err := h.service.GetMedicinePrices(c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(prices)
+}
+
 // ============ PRESCRIPTION HANDLERS ============
 
 func (h *HospitalHandler) GetAllPrescriptions(c *fiber.Ctx) error {
-	prescriptions, err := h.service.GetAllPrescriptions()
+	var filter models.PrescriptionFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return errInvalidQuery
+	}
+	filter.Normalize()
+
+	prescriptions, total, err := h.service.GetAllPrescriptions(filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+
+	setPageHeaders(c, filter.ListQuery, total)
 	return c.JSON(prescriptions)
 }
 
 func (h *HospitalHandler) CreatePrescription(c *fiber.Ctx) error {
 	var req models.CreatePrescriptionRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	// Dokter yang login adalah penulis resep
+	if middleware.UserRole(c) == models.RoleDoctor {
+		req.DoctorName = middleware.UserName(c)
 	}
 
 	prescription, err := h.service.CreatePrescription(&req)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.Status(201).JSON(prescription)
 }
 
+// CheckPrescription adalah dry-run CreatePrescription: mengembalikan semua
+// konflik alergi, interaksi obat dan peringatan stok tanpa menyimpan resep
+func (h *HospitalHandler) CheckPrescription(c *fiber.Ctx) error {
+	var req models.CreatePrescriptionRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	check, err := h.service.CheckPrescription(&req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(check)
+}
+
 func (h *HospitalHandler) UpdatePrescriptionStatus(c *fiber.Ctx) error {
 	id := c.Params("id")
-	action := c.Query("action") // process or finish
+	action := c.Query("action") // verify, process, ready, finish, cancel or reject
 
-	var err error
-	if action == "process" {
-		err = h.service.ProcessPrescription(id)
-	} else if action == "finish" {
-		err = h.service.FinishPrescription(id)
-	} else {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid action. Use ?action=process or ?action=finish"})
+	status, ok := services.PrescriptionStatusForAction(action)
+	if !ok {
+		return services.Validation("invalid_action", "Invalid action. Use ?action=verify|process|ready|finish|cancel|reject")
 	}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	// Hanya apoteker yang mengerjakan resep; dokter hanya boleh membatalkan
+	allowed := []string{models.RolePharmacist, models.RoleAdmin}
+	if status == models.PrescriptionStatusCancelled {
+		allowed = append(allowed, models.RoleDoctor)
+	}
+	if !middleware.HasRole(c, allowed...) {
+		return fiber.NewError(fiber.StatusForbidden, "Forbidden: your role cannot "+action+" prescriptions")
+	}
+
+	// Body opsional, hanya berisi catatan
+	var req models.UpdatePrescriptionStatusRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errInvalidBody
+		}
+	}
+
+	if err := h.service.TransitionPrescription(id, status, middleware.UserName(c), req.Note); err != nil {
+		return err
 	}
 
 	return c.JSON(fiber.Map{"message": "Status updated successfully"})
@@ -100,43 +211,245 @@ func (h *HospitalHandler) UpdatePrescriptionStatus(c *fiber.Ctx) error {
 // ============ PATIENT HANDLERS ============
 
 func (h *HospitalHandler) GetAllPatients(c *fiber.Ctx) error {
-	patients, err := h.service.GetAllPatients()
+	var filter models.PatientFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return errInvalidQuery
+	}
+	filter.Normalize()
+
+	patients, total, err := h.service.GetAllPatients(filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+
+	setPageHeaders(c, filter.ListQuery, total)
 	return c.JSON(patients)
 }
 
 func (h *HospitalHandler) AddPatient(c *fiber.Ctx) error {
 	var req models.AddPatientRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
 	}
 
-	patient, err := h.service.AddPatient(&req)
+	patient, created, err := h.service.AddPatient(&req)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
+	// 200 berarti NIK sudah terdaftar dan pasien lama yang dipakai
+	if !created {
+		return c.JSON(patient)
+	}
 	return c.Status(201).JSON(patient)
 }
 
+func (h *HospitalHandler) UpdatePatientAllergens(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.UpdateAllergensRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	patient, err := h.service.UpdatePatientAllergens(id, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(patient)
+}
+
+func (h *HospitalHandler) GetPatientPrescriptions(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	prescriptions, err := h.service.GetPatientPrescriptions(id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(prescriptions)
+}
+
 func (h *HospitalHandler) RemovePatient(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	if err := h.service.RemovePatient(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	err := h.service.RemovePatient(id)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(fiber.Map{"message": "Patient removed successfully"})
+	return c.JSON(fiber.Map{"message": "Patient removed from queue"})
+}
+
+// ============ QUEUE HANDLERS ============
+
+func (h *HospitalHandler) GetQueue(c *fiber.Ctx) error {
+	visits, err := h.service.GetQueue(c.Query("polyclinic"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(visits)
+}
+
+func (h *HospitalHandler) EnqueuePatient(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	// Body opsional: tanpa body pasien masuk poli umum dengan prioritas otomatis
+	var req models.EnqueueRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, h.validator, &req); err != nil {
+			return err
+		}
+	}
+
+	visit, err := h.service.EnqueuePatient(id, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(201).JSON(visit)
+}
+
+func (h *HospitalHandler) CallNextPatient(c *fiber.Ctx) error {
+	var req models.CallNextRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errInvalidBody
+		}
+	}
+
+	visit, err := h.service.CallNextPatient(req.Polyclinic)
+	if err != nil {
+		return err
+	}
+	return c.JSON(visit)
+}
+
+func (h *HospitalHandler) SkipVisit(c *fiber.Ctx) error {
+	return h.changeVisit(c, h.service.SkipVisit)
+}
+
+func (h *HospitalHandler) RecallVisit(c *fiber.Ctx) error {
+	return h.changeVisit(c, h.service.RecallVisit)
+}
+
+func (h *HospitalHandler) UpdateVisitStatus(c *fiber.Ctx) error {
+	var req models.UpdateVisitStatusRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	return h.changeVisit(c, func(id uint) (*models.Visit, error) {
+		return h.service.UpdateVisitStatus(id, req.Status)
+	})
+}
+
+// changeVisit menjalankan perubahan status kunjungan untuk visit di parameter :id
+func (h *HospitalHandler) changeVisit(c *fiber.Ctx, change func(id uint) (*models.Visit, error)) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return invalidParam("visit id")
+	}
+
+	visit, err := change(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(visit)
 }
 
 // ============ LOG HANDLERS ============
 
 func (h *HospitalHandler) GetAllLogs(c *fiber.Ctx) error {
-	logs, err := h.service.GetAllLogs()
+	var filter models.LogFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return errInvalidQuery
+	}
+	filter.Normalize()
+
+	logs, total, err := h.service.GetAllLogs(filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+
+	setPageHeaders(c, filter.ListQuery, total)
 	return c.JSON(logs)
 }
+
+// ============ ALERT HANDLERS ============
+
+func (h *HospitalHandler) GetAlerts(c *fiber.Ctx) error {
+	// Default hanya alert yang masih Open; ?status=all untuk semua
+	status := c.Query("status", models.AlertStatusOpen)
+	if status == "all" {
+		status = ""
+	}
+
+	alerts, err := h.service.GetAlerts(status)
+	if err != nil {
+		return err
+	}
+	return c.JSON(alerts)
+}
+
+func (h *HospitalHandler) AcknowledgeAlert(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return invalidParam("alert id")
+	}
+
+	alert, err := h.service.AcknowledgeAlert(uint(id), middleware.UserName(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(alert)
+}
+
+func (h *HospitalHandler) ResolveAlert(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return invalidParam("alert id")
+	}
+
+	alert, err := h.service.ResolveAlert(uint(id), middleware.UserName(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(alert)
+}
+
+// ============ AUTH HANDLERS ============
+
+func (h *HospitalHandler) Login(c *fiber.Ctx) error {
+	var req models.HospitalLoginRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	tokens, user, err := h.service.Login(req.Username, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user,
+	})
+}
+
+func (h *HospitalHandler) CreateUser(c *fiber.Ctx) error {
+	var req models.CreateHospitalUserRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+
+	user, err := h.service.CreateUser(&req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(201).JSON(user)
+}
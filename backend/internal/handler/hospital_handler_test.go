@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/migrations"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/validation"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// newTestDB membuat database SQLite baru yang sudah dimigrasi
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	cfg := database.Config{Driver: database.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")}
+	if err := cfg.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	migrator, err := migrations.New(sqlDB, cfg.Driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestApp menyiapkan Fiber dengan ErrorHandler asli, service di atas SQLite
+// yang sudah dimigrasi, dan token staf dengan role yang diberikan
+func newTestApp(t *testing.T, role string) (*fiber.App, *HospitalHandler, services.HospitalService) {
+	t.Helper()
+	db := newTestDB(t)
+	repo := repository.NewHospitalRepo(db, nil, repository.CacheTTL{})
+	tokens := services.NewTokenService(repository.NewTokenRepo(db), services.TokenConfig{Secret: "test"})
+	service := services.NewHospitalService(repo, tokens, services.NewEventBus(), services.HospitalConfig{})
+	handler := NewHospitalHandler(service, validation.New(repo))
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"role": role, "name": "Tester"}})
+		return c.Next()
+	})
+	return app, handler, service
+}
+
+func doJSON(t *testing.T, app *fiber.App, method, url, body string) (int, ErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out ErrorResponse
+	if resp.StatusCode >= 400 {
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("error body %s: %v", data, err)
+		}
+	}
+	return resp.StatusCode, out
+}
+
+// fieldRules mengambil pasangan field -> rule dari details.fields
+func fieldRules(t *testing.T, body ErrorResponse) map[string]string {
+	t.Helper()
+	raw, err := json.Marshal(body.Details["fields"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []validation.FieldError
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+	rules := map[string]string{}
+	for _, f := range fields {
+		if f.Message == "" {
+			t.Errorf("field %s has no message", f.Field)
+		}
+		rules[f.Field] = f.Rule
+	}
+	return rules
+}
+
+func TestCreatePrescriptionValidationErrorShape(t *testing.T) {
+	app, h, _ := newTestApp(t, models.RoleDoctor)
+	app.Post("/prescriptions", h.CreatePrescription)
+
+	status, body := doJSON(t, app, "POST", "/prescriptions",
+		`{"patient_dob":"1990-31-01","items":[{"medicine_id":"NOPE","qty":0}]}`)
+
+	if status != fiber.StatusBadRequest || body.Code != "validation_failed" {
+		t.Fatalf("got %d %s, want 400 validation_failed", status, body.Code)
+	}
+	want := map[string]string{
+		"patient_dob":          "date",
+		"patient_id":           "required",
+		"items[0].qty":         "gt",
+		"items[0].medicine_id": "exists",
+	}
+	got := fieldRules(t, body)
+	if len(got) != len(want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("field %s rule = %q, want %q", field, got[field], rule)
+		}
+	}
+	if !strings.Contains(body.Error, "(and 3 more)") {
+		t.Errorf("error = %q, want summary of all fields", body.Error)
+	}
+}
+
+func TestCreateMedicineRejectsInvalidFields(t *testing.T) {
+	app, h, _ := newTestApp(t, models.RoleLogistics)
+	app.Post("/medicines", h.CreateMedicine)
+
+	status, body := doJSON(t, app, "POST", "/medicines", `{"name":"","stock":-7,"price":-100,"expiry":"soon"}`)
+
+	if status != fiber.StatusBadRequest || body.Code != "validation_failed" {
+		t.Fatalf("got %d %s, want 400 validation_failed", status, body.Code)
+	}
+	got := fieldRules(t, body)
+	want := map[string]string{"name": "required", "stock": "gte", "price": "gte", "expiry": "date"}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("field %s rule = %q, want %q", field, got[field], rule)
+		}
+	}
+}
+
+func TestUpdatePrescriptionStatusIllegalTransitionReturns409(t *testing.T) {
+	app, h, service := newTestApp(t, models.RolePharmacist)
+	app.Put("/prescriptions/:id/status", h.UpdatePrescriptionStatus)
+
+	medicine, err := service.CreateMedicine(&models.CreateMedicineRequest{Name: "Amoxicillin", Stock: 10, Expiry: "2099-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	patient, _, err := service.AddPatient(&models.AddPatientRequest{Name: "Budi", Dob: "1990-01-01", RegisterOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prescription, err := service.CreatePrescription(&models.CreatePrescriptionRequest{
+		PatientID: patient.ID,
+		Items:     []models.CreatePrescriptionItemRequest{{MedicineID: medicine.ID, Qty: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pending tidak boleh langsung Ready atau Selesai
+	for _, action := range []string{"ready", "finish", "process"} {
+		status, body := doJSON(t, app, "PUT", "/prescriptions/"+prescription.ID+"/status?action="+action, "")
+		if status != fiber.StatusConflict || body.Code != "invalid_transition" {
+			t.Errorf("action %s: got %d %s, want 409 invalid_transition", action, status, body.Code)
+		}
+	}
+
+	status, _ := doJSON(t, app, "PUT", "/prescriptions/"+prescription.ID+"/status?action=verify", "")
+	if status != fiber.StatusOK {
+		t.Errorf("action verify: got %d, want 200", status)
+	}
+}
+
+func TestListMedicinesWithoutPagingReturnsEverything(t *testing.T) {
+	app, h, service := newTestApp(t, models.RoleLogistics)
+	app.Get("/medicines", h.GetAllMedicines)
+
+	const count = models.DefaultPageSize + 5
+	for i := 0; i < count; i++ {
+		if _, err := service.CreateMedicine(&models.CreateMedicineRequest{Name: fmt.Sprintf("Obat %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query     string
+		items     int
+		pageSize  string
+		pageCount string
+	}{
+		{"", count, strconv.Itoa(count), "1"},
+		{"?page=2", count - models.DefaultPageSize, strconv.Itoa(models.DefaultPageSize), "2"},
+		{"?page_size=10", 10, "10", "6"},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", "/medicines"+tt.query, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var medicines []models.Medicine
+		err = json.NewDecoder(resp.Body).Decode(&medicines)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(medicines) != tt.items {
+			t.Errorf("%q: got %d medicines, want %d", tt.query, len(medicines), tt.items)
+		}
+		if got := resp.Header.Get("X-Page-Size"); got != tt.pageSize {
+			t.Errorf("%q: X-Page-Size = %s, want %s", tt.query, got, tt.pageSize)
+		}
+		if got := resp.Header.Get("X-Total-Pages"); got != tt.pageCount {
+			t.Errorf("%q: X-Total-Pages = %s, want %s", tt.query, got, tt.pageCount)
+		}
+	}
+}
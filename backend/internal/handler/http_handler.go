@@ -1,36 +1,39 @@
 package handlers
 
 import (
+	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/service"
+	"backend/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type ProjectHandler struct {
-	service services.ProjectService
+	service   services.ProjectService
+	validator *validation.Validator
 }
 
-func NewProjectHandler(service services.ProjectService) *ProjectHandler {
-	return &ProjectHandler{service}
+func NewProjectHandler(service services.ProjectService, validator *validation.Validator) *ProjectHandler {
+	return &ProjectHandler{service, validator}
 }
 
 func (h *ProjectHandler) GetAll(c *fiber.Ctx) error {
 	projects, err := h.service.GetAllProjects()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.JSON(projects)
 }
 
 func (h *ProjectHandler) Create(c *fiber.Ctx) error {
 	var req models.CreateProjectRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
 	}
 	createdProject, err := h.service.CreateProject(req)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.Status(201).JSON(createdProject)
 }
@@ -38,7 +41,7 @@ func (h *ProjectHandler) Create(c *fiber.Ctx) error {
 func (h *ProjectHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if err := h.service.RemoveProject(id); err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.JSON(fiber.Map{"message": "Deleted successfully"})
 }
@@ -46,20 +49,59 @@ func (h *ProjectHandler) Delete(c *fiber.Ctx) error {
 // Implementasi Baru: Handler Login
 func (h *ProjectHandler) Login(c *fiber.Ctx) error {
 	type LoginInput struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+		Username string `json:"username" validate:"required"`
+		Password string `json:"password" validate:"required"`
 	}
 	var input LoginInput
 
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	if err := parseBody(c, h.validator, &input); err != nil {
+		return err
 	}
 
-	token, err := h.service.Login(input.Username, input.Password)
+	// Password salah 401, akun terkunci 429
+	tokens, err := h.service.Login(input.Username, input.Password)
 	if err != nil {
-		// Return 401 Unauthorized jika gagal login
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(fiber.Map{"token": token})
+	return c.JSON(tokens)
+}
+
+func (h *ProjectHandler) CreateAdmin(c *fiber.Ctx) error {
+	var req models.CreateAdminRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+	admin, err := h.service.CreateAdmin(req)
+	if err != nil {
+		return err
+	}
+	return c.Status(201).JSON(admin)
+}
+
+// ChangePassword mengganti password milik admin yang sedang login
+func (h *ProjectHandler) ChangePassword(c *fiber.Ctx) error {
+	var req models.ChangePasswordRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+	if err := h.service.ChangePassword(middleware.AdminID(c), req.OldPassword, req.NewPassword); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"message": "Password changed successfully, please log in again"})
+}
+
+func (h *ProjectHandler) ResetPassword(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return invalidParam("admin id")
+	}
+	var req models.ResetPasswordRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return err
+	}
+	if err := h.service.ResetPassword(uint(id), req.NewPassword); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"message": "Password reset successfully"})
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/validation"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminPasswordEndpointsValidateBody(t *testing.T) {
+	db := newTestDB(t)
+	tokens := services.NewTokenService(repository.NewTokenRepo(db), services.TokenConfig{Secret: "test"})
+	service := services.NewProjectService(repository.NewProjectRepo(db), tokens)
+	h := NewProjectHandler(service, validation.New(repository.NewHospitalRepo(db, nil, repository.CacheTTL{})))
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/admins", h.CreateAdmin)
+	app.Put("/password", h.ChangePassword)
+	app.Put("/admins/:id/password", h.ResetPassword)
+
+	tests := []struct {
+		method, url, body string
+		want              map[string]string
+	}{
+		{"POST", "/admins", `{"password":"short"}`, map[string]string{"username": "required", "password": "min"}},
+		{"PUT", "/password", `{"new_password":"short"}`, map[string]string{"old_password": "required", "new_password": "min"}},
+		{"PUT", "/admins/1/password", `{}`, map[string]string{"new_password": "required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			status, body := doJSON(t, app, tt.method, tt.url, tt.body)
+			if status != fiber.StatusBadRequest || body.Code != "validation_failed" {
+				t.Fatalf("got %d %s, want 400 validation_failed", status, body.Code)
+			}
+			got := fieldRules(t, body)
+			if len(got) != len(tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+			for field, rule := range tt.want {
+				if got[field] != rule {
+					t.Errorf("field %s rule = %q, want %q", field, got[field], rule)
+				}
+			}
+		})
+	}
+}
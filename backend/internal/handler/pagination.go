@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PageHeaders adalah header metadata paginasi. Body list tetap berupa array
+// agar klien lama tidak berubah; header ini perlu di-expose lewat CORS.
+var PageHeaders = []string{"X-Total-Count", "X-Page", "X-Page-Size", "X-Total-Pages"}
+
+func setPageHeaders(c *fiber.Ctx, q models.ListQuery, total int64) {
+	if q.Unpaged() {
+		// Semua baris dalam satu halaman
+		q.Page, q.PageSize = 1, int(total)
+	}
+	var totalPages int64
+	if q.PageSize > 0 {
+		totalPages = (total + int64(q.PageSize) - 1) / int64(q.PageSize)
+	}
+	c.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Set("X-Page", strconv.Itoa(q.Page))
+	c.Set("X-Page-Size", strconv.Itoa(q.PageSize))
+	c.Set("X-Total-Pages", strconv.FormatInt(totalPages, 10))
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"backend/internal/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseBody membaca body JSON ke req lalu memvalidasinya, sehingga service hanya
+// menerima request yang bentuknya sudah benar. Field yang salah dikembalikan
+// sekaligus sebagai validation.Error.
+func parseBody(c *fiber.Ctx, v *validation.Validator, req interface{}) error {
+	if err := c.BodyParser(req); err != nil {
+		return errInvalidBody
+	}
+	return v.Struct(req)
+}
@@ -0,0 +1,60 @@
+// Package idformat mengurai format ID yang bisa dikonfigurasi, mis.
+// "RSP-{date:20060102}-{seq:4}". Dipakai config (validasi saat start) dan
+// service (membuat ID dari sequence database).
+package idformat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Jenis ID yang dibuat dari sequence database
+const (
+	Prescription = "prescription"
+	Medicine     = "medicine"
+	Patient      = "patient"
+)
+
+// Defaults dipakai untuk jenis ID yang formatnya tidak diatur.
+// Lebar angka sengaja beda dari ID lama (OBT001, P-001) agar tidak bentrok.
+var Defaults = map[string]string{
+	Prescription: "RSP-{date:20060102}-{seq:4}",
+	Medicine:     "OBT-{seq:5}",
+	Patient:      "P-{seq:6}",
+}
+
+var (
+	dateToken = regexp.MustCompile(`\{date:([^}]+)\}`)
+	seqToken  = regexp.MustCompile(`\{seq(?::(\d+))?\}`)
+)
+
+// Validate memastikan format punya tepat satu {seq} dan paling banyak satu {date:LAYOUT}
+func Validate(format string) error {
+	if n := len(seqToken.FindAllString(format, -1)); n != 1 {
+		return fmt.Errorf("id format %q must contain exactly one {seq} or {seq:N}", format)
+	}
+	if n := len(dateToken.FindAllString(format, -1)); n > 1 {
+		return fmt.Errorf("id format %q may contain at most one {date:LAYOUT}", format)
+	}
+	return nil
+}
+
+// Period mengembalikan bagian tanggal format pada waktu t, atau "" jika format
+// tidak punya {date:LAYOUT}. Sequence di-reset setiap periode berganti.
+func Period(format string, t time.Time) string {
+	if match := dateToken.FindStringSubmatch(format); match != nil {
+		return t.Format(match[1])
+	}
+	return ""
+}
+
+// Render merangkai ID dari format, periode (hasil Period) dan nilai sequence
+func Render(format, period string, seq int64) string {
+	id := dateToken.ReplaceAllLiteralString(format, period)
+	return seqToken.ReplaceAllStringFunc(id, func(token string) string {
+		width, _ := strconv.Atoi(seqToken.FindStringSubmatch(token)[1])
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
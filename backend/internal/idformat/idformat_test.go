@@ -0,0 +1,36 @@
+package idformat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		format string
+		valid  bool
+	}{
+		{"RSP-{date:20060102}-{seq:4}", true},
+		{"OBT-{seq}", true},
+		{"OBT-001", false},
+		{"{seq}-{seq}", false},
+		{"{date:2006}{date:01}-{seq}", false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.format); (err == nil) != tt.valid {
+			t.Errorf("Validate(%q) = %v, want valid=%v", tt.format, err, tt.valid)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2025, 3, 9, 10, 0, 0, 0, time.UTC)
+	format := Defaults[Prescription]
+	period := Period(format, now)
+	if got := Render(format, period, 7); got != "RSP-20250309-0007" {
+		t.Errorf("Render = %q, want RSP-20250309-0007", got)
+	}
+	if got := Render("OBT-{seq}", Period("OBT-{seq}", now), 12); got != "OBT-12" {
+		t.Errorf("Render = %q, want OBT-12", got)
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// claims mengambil MapClaims dari token yang sudah diverifikasi jwtware
+func claims(c *fiber.Ctx) jwt.MapClaims {
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return nil
+	}
+	mapClaims, _ := token.Claims.(jwt.MapClaims)
+	return mapClaims
+}
+
+// TokenID mengembalikan jti dari access token, dipakai untuk revocation
+func TokenID(c *fiber.Ctx) string {
+	jti, _ := claims(c)["jti"].(string)
+	return jti
+}
+
+// TokenExpiry mengembalikan waktu kedaluwarsa access token
+func TokenExpiry(c *fiber.Ctx) time.Time {
+	exp, _ := claims(c)["exp"].(float64)
+	return time.Unix(int64(exp), 0)
+}
+
+// TokenSubject mengembalikan pemilik access token (claim sub), mis. "admin:1"
+func TokenSubject(c *fiber.Ctx) string {
+	sub, _ := claims(c)["sub"].(string)
+	return sub
+}
+
+// TokenIssuedAt mengembalikan waktu access token diterbitkan
+func TokenIssuedAt(c *fiber.Ctx) time.Time {
+	iat, _ := claims(c)["iat"].(float64)
+	return time.Unix(int64(iat), 0)
+}
+
+// UserRole mengembalikan role dari token staf yang sedang login
+func UserRole(c *fiber.Ctx) string {
+	role, _ := claims(c)["role"].(string)
+	return role
+}
+
+// UserName mengembalikan nama staf yang sedang login, dipakai sebagai PIC
+func UserName(c *fiber.Ctx) string {
+	name, _ := claims(c)["name"].(string)
+	return name
+}
+
+// AdminID mengembalikan ID Admin portfolio dari token yang sedang login
+func AdminID(c *fiber.Ctx) uint {
+	// Angka di JWT claims selalu di-decode sebagai float64
+	id, _ := claims(c)["admin_id"].(float64)
+	return uint(id)
+}
+
+// HasRole mengecek apakah staf yang sedang login memiliki salah satu role
+func HasRole(c *fiber.Ctx, roles ...string) bool {
+	role := UserRole(c)
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRoles menolak request dengan 403 jika role staf tidak termasuk roles.
+// Harus dipasang setelah jwtware.
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !HasRole(c, roles...) {
+			return fiber.NewError(fiber.StatusForbidden, "Forbidden: your role cannot access this resource")
+		}
+		return c.Next()
+	}
+}
+
+// RequireStaff menerima semua token staf rumah sakit (yang punya claim role)
+func RequireStaff() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if UserRole(c) == "" {
+			return fiber.NewError(fiber.StatusForbidden, "Forbidden: hospital account required")
+		}
+		return c.Next()
+	}
+}
+
+// RequireAdmin memastikan token berasal dari login Admin portfolio, bukan akun staf
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := claims(c)["admin_id"]; !ok {
+			return fiber.NewError(fiber.StatusForbidden, "Forbidden: admin account required")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,303 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File migrasi ada di <dialect>/NNNN_nama.up.sql dan NNNN_nama.down.sql,
+// ikut ter-embed di binary sehingga deploy tidak perlu menyalin folder SQL
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Tabel pencatat versi yang sudah dijalankan
+const table = "schema_migrations"
+
+// Key pg_advisory_lock agar replika yang boot bersamaan tidak migrasi dua kali
+const advisoryLockKey = 72150419
+
+// Migration adalah satu pasang file up/down
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status satu migrasi; AppliedAt nil jika belum dijalankan
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Migrator menjalankan migrasi ter-embed untuk satu dialect database
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New memuat migrasi untuk dialect ("postgres" atau "sqlite")
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+
+		body, err := files.ReadFile(path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up menjalankan semua migrasi yang belum tercatat, masing-masing dalam transaksinya
+// sendiri. Mengembalikan migrasi yang baru dijalankan.
+func (m *Migrator) Up() ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(context.Background(), conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := m.run(conn, mig.Up, func(tx *sql.Tx) error {
+				_, err := tx.Exec("INSERT INTO "+table+" (version, name, applied_at) VALUES ($1, $2, $3)",
+					mig.Version, mig.Name, time.Now().UTC())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down membatalkan `steps` migrasi terakhir yang sudah dijalankan, dari yang terbaru
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(context.Background(), conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			err := m.run(conn, mig.Down, func(tx *sql.Tx) error {
+				_, err := tx.Exec("DELETE FROM "+table+" WHERE version = $1", mig.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Status mengembalikan semua migrasi yang dikenal binary beserta waktu dijalankannya.
+// Versi yang tercatat di database tapi tidak ada di binary ikut ditampilkan.
+func (m *Migrator) Status() ([]Status, error) {
+	conn, err := m.db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTable(conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(context.Background(), conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			status.AppliedAt = &at.appliedAt
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, at := range applied {
+		statuses = append(statuses, Status{Version: version, Name: at.name + " (missing from binary)", AppliedAt: &at.appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending mengembalikan migrasi yang belum dijalankan. Berbeda dengan Status,
+// tidak membuat tabel schema_migrations sehingga aman dipanggil berulang (readiness check).
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) ensureTable(conn *sql.Conn) error {
+	// Driver SQLite hanya mengembalikan time.Time untuk kolom bertipe DATETIME
+	timestampType := "timestamptz"
+	if m.dialect == "sqlite" {
+		timestampType = "datetime"
+	}
+	_, err := conn.ExecContext(context.Background(), `CREATE TABLE IF NOT EXISTS `+table+` (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at `+timestampType+` NOT NULL
+	)`)
+	return err
+}
+
+// run mengeksekusi satu file SQL (boleh berisi banyak statement) lalu mencatat
+// versinya di transaksi yang sama
+func (m *Migrator) run(conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock memakai satu koneksi untuk seluruh proses migrasi dan, di PostgreSQL,
+// menahan advisory lock selama migrasi berjalan. SQLite sudah mengunci seluruh
+// file saat transaksi menulis.
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect == "postgres" {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockKey)
+	}
+
+	if err := m.ensureTable(conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
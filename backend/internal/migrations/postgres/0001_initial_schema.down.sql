@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS logs;
+DROP TABLE IF EXISTS patients;
+DROP TABLE IF EXISTS prescription_items;
+DROP TABLE IF EXISTS prescriptions;
+DROP TABLE IF EXISTS medicines;
+DROP TABLE IF EXISTS admins;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,89 @@
+-- Skema awal (setara dengan AutoMigrate sebelum ada migrasi berversi).
+-- Semua statement idempotent supaya database lama yang dibuat AutoMigrate ikut tercatat.
+
+CREATE TABLE IF NOT EXISTS projects (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    title       text NOT NULL,
+    description text,
+    image_url   text,
+    repo_url    text,
+    demo_url    text,
+    tags        text,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS admins (
+    id       bigserial PRIMARY KEY,
+    username text NOT NULL UNIQUE,
+    password text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS medicines (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    type       text,
+    stock      bigint,
+    price      bigint,
+    expiry     text,
+    location   text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS prescriptions (
+    id           text PRIMARY KEY,
+    patient_name text,
+    patient_dob  text,
+    allergies    text,
+    doctor_name  text,
+    date         text,
+    status       text,
+    total_price  bigint,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS prescription_items (
+    id              bigserial PRIMARY KEY,
+    prescription_id text,
+    medicine_id     text,
+    name            text,
+    qty             bigint,
+    price           bigint,
+    signa           text
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_prescriptions_items') THEN
+        ALTER TABLE prescription_items ADD CONSTRAINT fk_prescriptions_items
+            FOREIGN KEY (prescription_id) REFERENCES prescriptions(id);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS patients (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    dob        text,
+    status     text,
+    allergies  text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS logs (
+    id            bigserial PRIMARY KEY,
+    date          text,
+    type          text,
+    medicine_name text,
+    qty           bigint,
+    ref           text,
+    pic           text,
+    created_at    timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_prescription_items_prescription_id ON prescription_items(prescription_id);
+CREATE INDEX IF NOT EXISTS idx_logs_created_at ON logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_status ON prescriptions(status);
+CREATE INDEX IF NOT EXISTS idx_patients_created_at ON patients(created_at DESC);
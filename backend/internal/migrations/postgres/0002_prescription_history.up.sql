@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS prescription_histories (
+    id              bigserial PRIMARY KEY,
+    prescription_id text,
+    from_status     text,
+    status          text,
+    pic             text,
+    note            text,
+    created_at      timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_prescription_histories_prescription_id ON prescription_histories(prescription_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_prescriptions_history_logs') THEN
+        ALTER TABLE prescription_histories ADD CONSTRAINT fk_prescriptions_history_logs
+            FOREIGN KEY (prescription_id) REFERENCES prescriptions(id);
+    END IF;
+END $$;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS hospital_users (
+    id         bigserial PRIMARY KEY,
+    username   text NOT NULL UNIQUE,
+    name       text NOT NULL,
+    role       text NOT NULL,
+    password   text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
@@ -0,0 +1,2 @@
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS failed_attempts bigint NOT NULL DEFAULT 0;
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS locked_until timestamptz;
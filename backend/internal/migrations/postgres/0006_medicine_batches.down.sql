@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS prescription_item_batches;
+DROP TABLE IF EXISTS medicine_batches;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS medicine_batches (
+    id            bigserial PRIMARY KEY,
+    medicine_id   text NOT NULL,
+    lot_number    text,
+    expiry        text,
+    qty           bigint,
+    initial_qty   bigint,
+    supplier      text,
+    received_date text,
+    created_at    timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_medicine_batches_medicine_id ON medicine_batches(medicine_id);
+CREATE INDEX IF NOT EXISTS idx_medicine_batches_expiry ON medicine_batches(expiry);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_medicines_batches') THEN
+        ALTER TABLE medicine_batches ADD CONSTRAINT fk_medicines_batches
+            FOREIGN KEY (medicine_id) REFERENCES medicines(id);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS prescription_item_batches (
+    id                   bigserial PRIMARY KEY,
+    prescription_item_id bigint NOT NULL,
+    batch_id             bigint NOT NULL,
+    lot_number           text,
+    expiry               text,
+    qty                  bigint
+);
+
+CREATE INDEX IF NOT EXISTS idx_prescription_item_batches_prescription_item_id ON prescription_item_batches(prescription_item_id);
+CREATE INDEX IF NOT EXISTS idx_prescription_item_batches_batch_id ON prescription_item_batches(batch_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_prescription_items_batches') THEN
+        ALTER TABLE prescription_item_batches ADD CONSTRAINT fk_prescription_items_batches
+            FOREIGN KEY (prescription_item_id) REFERENCES prescription_items(id);
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS alerts;
+ALTER TABLE medicines DROP COLUMN IF EXISTS expiry_warning_days;
+ALTER TABLE medicines DROP COLUMN IF EXISTS reorder_threshold;
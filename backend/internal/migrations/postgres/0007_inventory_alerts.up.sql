@@ -0,0 +1,22 @@
+ALTER TABLE medicines ADD COLUMN IF NOT EXISTS reorder_threshold bigint NOT NULL DEFAULT 10;
+ALTER TABLE medicines ADD COLUMN IF NOT EXISTS expiry_warning_days bigint NOT NULL DEFAULT 30;
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id              bigserial PRIMARY KEY,
+    type            text NOT NULL,
+    medicine_id     text NOT NULL,
+    medicine_name   text,
+    batch_id        bigint,
+    message         text,
+    status          text NOT NULL,
+    acknowledged_by text,
+    acknowledged_at timestamptz,
+    resolved_by     text,
+    resolved_at     timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_type ON alerts(type);
+CREATE INDEX IF NOT EXISTS idx_alerts_medicine_id ON alerts(medicine_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
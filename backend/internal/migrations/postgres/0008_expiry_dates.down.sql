@@ -0,0 +1,4 @@
+ALTER TABLE prescription_item_batches ALTER COLUMN expiry TYPE text USING COALESCE(expiry::text, '');
+ALTER TABLE medicine_batches ALTER COLUMN received_date TYPE text USING COALESCE(received_date::text, '');
+ALTER TABLE medicine_batches ALTER COLUMN expiry TYPE text USING COALESCE(expiry::text, '');
+ALTER TABLE medicines ALTER COLUMN expiry TYPE text USING COALESCE(expiry::text, '');
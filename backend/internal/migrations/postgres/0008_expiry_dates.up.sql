@@ -0,0 +1,5 @@
+-- Tanggal expiry dulu disimpan sebagai text; string kosong menjadi NULL
+ALTER TABLE medicines ALTER COLUMN expiry TYPE date USING NULLIF(expiry::text, '')::date;
+ALTER TABLE medicine_batches ALTER COLUMN expiry TYPE date USING NULLIF(expiry::text, '')::date;
+ALTER TABLE medicine_batches ALTER COLUMN received_date TYPE date USING NULLIF(received_date::text, '')::date;
+ALTER TABLE prescription_item_batches ALTER COLUMN expiry TYPE date USING NULLIF(expiry::text, '')::date;
@@ -0,0 +1,3 @@
+ALTER TABLE prescription_items DROP COLUMN IF EXISTS allergy_override_reason;
+DROP TABLE IF EXISTS patient_allergens;
+DROP TABLE IF EXISTS medicine_ingredients;
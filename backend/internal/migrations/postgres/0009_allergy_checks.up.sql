@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS medicine_ingredients (
+    id          bigserial PRIMARY KEY,
+    medicine_id text NOT NULL,
+    ingredient  text NOT NULL,
+    drug_class  text
+);
+
+CREATE INDEX IF NOT EXISTS idx_medicine_ingredients_medicine_id ON medicine_ingredients(medicine_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_medicines_ingredients') THEN
+        ALTER TABLE medicine_ingredients ADD CONSTRAINT fk_medicines_ingredients
+            FOREIGN KEY (medicine_id) REFERENCES medicines(id);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS patient_allergens (
+    id         bigserial PRIMARY KEY,
+    patient_id text NOT NULL,
+    allergen   text NOT NULL,
+    reaction   text
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_allergens_patient_id ON patient_allergens(patient_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_patients_allergens') THEN
+        ALTER TABLE patient_allergens ADD CONSTRAINT fk_patients_allergens
+            FOREIGN KEY (patient_id) REFERENCES patients(id);
+    END IF;
+END $$;
+
+ALTER TABLE prescription_items ADD COLUMN IF NOT EXISTS allergy_override_reason text;
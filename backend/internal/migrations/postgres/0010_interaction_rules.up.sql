@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS interaction_rules (
+    id         bigserial PRIMARY KEY,
+    subject_a  text NOT NULL,
+    subject_b  text NOT NULL,
+    severity   text NOT NULL,
+    message    text,
+    created_at timestamptz
+);
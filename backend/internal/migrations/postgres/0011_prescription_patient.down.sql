@@ -0,0 +1,3 @@
+ALTER TABLE prescriptions DROP CONSTRAINT IF EXISTS fk_prescriptions_patient;
+DROP INDEX IF EXISTS idx_prescriptions_patient_id;
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS patient_id;
@@ -0,0 +1,11 @@
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS patient_id text;
+
+CREATE INDEX IF NOT EXISTS idx_prescriptions_patient_id ON prescriptions(patient_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_prescriptions_patient') THEN
+        ALTER TABLE prescriptions ADD CONSTRAINT fk_prescriptions_patient
+            FOREIGN KEY (patient_id) REFERENCES patients(id) ON UPDATE CASCADE ON DELETE SET NULL;
+    END IF;
+END $$;
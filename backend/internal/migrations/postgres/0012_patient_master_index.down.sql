@@ -0,0 +1,17 @@
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS status text;
+
+-- Hanya kunjungan yang masih terbuka yang bisa dikembalikan ke kolom status
+UPDATE patients p SET status = v.status
+FROM visits v
+WHERE v.patient_id = p.id AND v.status IN ('Waiting', 'Examining') AND v.closed_at IS NULL;
+
+DROP TABLE IF EXISTS visits;
+
+DROP INDEX IF EXISTS idx_patients_nik;
+DROP INDEX IF EXISTS idx_patients_medical_record_number;
+ALTER TABLE patients DROP COLUMN IF EXISTS address;
+ALTER TABLE patients DROP COLUMN IF EXISTS phone;
+ALTER TABLE patients DROP COLUMN IF EXISTS blood_type;
+ALTER TABLE patients DROP COLUMN IF EXISTS gender;
+ALTER TABLE patients DROP COLUMN IF EXISTS nik;
+ALTER TABLE patients DROP COLUMN IF EXISTS medical_record_number;
@@ -0,0 +1,46 @@
+-- Pasien menjadi master index permanen; status antrean pindah ke tabel visits
+
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS medical_record_number text;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS nik text;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS gender text;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS blood_type text;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS phone text;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS address text;
+
+-- Pasien lama mendapat nomor rekam medis dari ID-nya (P-001 -> RM-001)
+UPDATE patients SET medical_record_number = 'RM-' || REPLACE(id, 'P-', '')
+WHERE medical_record_number IS NULL;
+
+ALTER TABLE patients ALTER COLUMN medical_record_number SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_medical_record_number ON patients(medical_record_number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_nik ON patients(nik);
+
+CREATE TABLE IF NOT EXISTS visits (
+    id         bigserial PRIMARY KEY,
+    patient_id text NOT NULL,
+    status     text NOT NULL,
+    closed_at  timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_visits_patient_id ON visits(patient_id);
+CREATE INDEX IF NOT EXISTS idx_visits_status ON visits(status);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_visits_patient') THEN
+        ALTER TABLE visits ADD CONSTRAINT fk_visits_patient
+            FOREIGN KEY (patient_id) REFERENCES patients(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+    END IF;
+END $$;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'patients' AND column_name = 'status') THEN
+        INSERT INTO visits (patient_id, status, created_at, updated_at)
+        SELECT id, status, now(), now() FROM patients WHERE status IN ('Waiting', 'Examining');
+        ALTER TABLE patients DROP COLUMN status;
+    END IF;
+END $$;
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS queue_counters;
+DROP INDEX IF EXISTS idx_visit_ticket;
+ALTER TABLE visits DROP COLUMN IF EXISTS started_at;
+ALTER TABLE visits DROP COLUMN IF EXISTS called_at;
+ALTER TABLE visits DROP COLUMN IF EXISTS skip_count;
+ALTER TABLE visits DROP COLUMN IF EXISTS priority;
+ALTER TABLE visits DROP COLUMN IF EXISTS ticket;
+ALTER TABLE visits DROP COLUMN IF EXISTS ticket_number;
+ALTER TABLE visits DROP COLUMN IF EXISTS queue_date;
+ALTER TABLE visits DROP COLUMN IF EXISTS polyclinic;
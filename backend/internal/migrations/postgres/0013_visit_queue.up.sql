@@ -0,0 +1,17 @@
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS polyclinic text NOT NULL DEFAULT 'UMUM';
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS queue_date date;
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS ticket_number bigint;
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS ticket text;
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS priority text NOT NULL DEFAULT 'regular';
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS skip_count bigint DEFAULT 0;
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS called_at timestamptz;
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS started_at timestamptz;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_visit_ticket ON visits(queue_date, polyclinic, ticket_number);
+
+CREATE TABLE IF NOT EXISTS queue_counters (
+    queue_date  date NOT NULL,
+    polyclinic  text NOT NULL,
+    last_number bigint NOT NULL,
+    PRIMARY KEY (queue_date, polyclinic)
+);
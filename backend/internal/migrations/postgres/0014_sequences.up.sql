@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS sequences (
+    name       text NOT NULL,
+    period     text NOT NULL,
+    last_value bigint NOT NULL,
+    PRIMARY KEY (name, period)
+);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS medicine_prices (
+    id             bigserial PRIMARY KEY,
+    medicine_id    text NOT NULL,
+    price          bigint NOT NULL,
+    effective_from timestamptz NOT NULL,
+    changed_by     text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_medicine_prices_medicine_id ON medicine_prices(medicine_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_medicines_prices') THEN
+        ALTER TABLE medicine_prices ADD CONSTRAINT fk_medicines_prices
+            FOREIGN KEY (medicine_id) REFERENCES medicines(id) ON UPDATE CASCADE ON DELETE CASCADE;
+    END IF;
+END $$;
+
+-- Harga katalog saat ini menjadi baris pertama riwayat
+INSERT INTO medicine_prices (medicine_id, price, effective_from, changed_by)
+SELECT m.id, COALESCE(m.price, 0), COALESCE(m.created_at, now()), 'migration'
+FROM medicines m
+WHERE NOT EXISTS (SELECT 1 FROM medicine_prices p WHERE p.medicine_id = m.id);
+
+-- Item resep mencatat baris harga yang dipakai; resep lama tetap memakai snapshot price
+ALTER TABLE prescription_items ADD COLUMN IF NOT EXISTS price_id bigint;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_prescription_items_price') THEN
+        ALTER TABLE prescription_items ADD CONSTRAINT fk_prescription_items_price
+            FOREIGN KEY (price_id) REFERENCES medicine_prices(id) ON DELETE SET NULL;
+    END IF;
+END $$;
@@ -0,0 +1,9 @@
+-- Pencabutan semua sesi milik satu subject (mis. setelah ganti password).
+-- Access token subject ini dengan iat <= revoked_before ditolak sampai expires_at.
+CREATE TABLE IF NOT EXISTS subject_revocations (
+    subject        text PRIMARY KEY,
+    revoked_before timestamptz NOT NULL,
+    expires_at     timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_subject_revocations_expires_at ON subject_revocations(expires_at);
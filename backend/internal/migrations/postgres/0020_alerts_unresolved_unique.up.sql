@@ -0,0 +1,13 @@
+-- Setiap replica menjalankan scheduler alert. Satu kondisi (jenis + obat + batch)
+-- hanya boleh punya satu alert yang belum resolved; duplikat lama ditutup dulu.
+UPDATE alerts SET status = 'Resolved', resolved_by = 'system', resolved_at = now(), updated_at = now()
+WHERE status <> 'Resolved'
+  AND id NOT IN (
+    SELECT MIN(id) FROM alerts
+    WHERE status <> 'Resolved'
+    GROUP BY type, medicine_id, COALESCE(batch_id, 0)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_unresolved_condition
+    ON alerts(type, medicine_id, COALESCE(batch_id, 0))
+    WHERE status <> 'Resolved';
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS logs;
+DROP TABLE IF EXISTS sequences;
+DROP TABLE IF EXISTS interaction_rules;
+DROP TABLE IF EXISTS prescription_histories;
+DROP TABLE IF EXISTS prescription_item_batches;
+DROP TABLE IF EXISTS prescription_items;
+DROP TABLE IF EXISTS prescriptions;
+DROP TABLE IF EXISTS queue_counters;
+DROP TABLE IF EXISTS visits;
+DROP TABLE IF EXISTS patient_allergens;
+DROP TABLE IF EXISTS patients;
+DROP TABLE IF EXISTS alerts;
+DROP TABLE IF EXISTS medicine_ingredients;
+DROP TABLE IF EXISTS medicine_batches;
+DROP TABLE IF EXISTS medicines;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS hospital_users;
+DROP TABLE IF EXISTS admins;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,250 @@
+-- SQLite baru didukung mulai skema versi 14, jadi seluruh skema saat itu dibuat
+-- dalam satu migrasi. Migrasi berikutnya memakai nomor yang sama dengan PostgreSQL.
+-- ID projects dibuat di aplikasi (tidak ada gen_random_uuid() di SQLite).
+
+CREATE TABLE projects (
+    id          uuid PRIMARY KEY,
+    title       text NOT NULL,
+    description text,
+    image_url   text,
+    repo_url    text,
+    demo_url    text,
+    tags        text,
+    created_at  datetime,
+    updated_at  datetime
+);
+
+CREATE TABLE admins (
+    id              integer PRIMARY KEY AUTOINCREMENT,
+    username        text NOT NULL UNIQUE,
+    password        text NOT NULL,
+    failed_attempts integer NOT NULL DEFAULT 0,
+    locked_until    datetime
+);
+
+CREATE TABLE hospital_users (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    username   text NOT NULL UNIQUE,
+    name       text NOT NULL,
+    role       text NOT NULL,
+    password   text NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+
+CREATE TABLE refresh_tokens (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    token_hash text NOT NULL,
+    family_id  text NOT NULL,
+    claims     text,
+    expires_at datetime,
+    revoked_at datetime,
+    created_at datetime
+);
+
+CREATE UNIQUE INDEX idx_refresh_tokens_token_hash ON refresh_tokens(token_hash);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+CREATE TABLE revoked_tokens (
+    jti        text PRIMARY KEY,
+    expires_at datetime
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+CREATE TABLE medicines (
+    id                  text PRIMARY KEY,
+    name                text NOT NULL,
+    type                text,
+    stock               integer,
+    price               integer,
+    expiry              date,
+    location            text,
+    reorder_threshold   integer NOT NULL DEFAULT 10,
+    expiry_warning_days integer NOT NULL DEFAULT 30,
+    created_at          datetime,
+    updated_at          datetime
+);
+
+CREATE TABLE medicine_batches (
+    id            integer PRIMARY KEY AUTOINCREMENT,
+    medicine_id   text NOT NULL REFERENCES medicines(id),
+    lot_number    text,
+    expiry        date,
+    qty           integer,
+    initial_qty   integer,
+    supplier      text,
+    received_date date,
+    created_at    datetime
+);
+
+CREATE INDEX idx_medicine_batches_medicine_id ON medicine_batches(medicine_id);
+CREATE INDEX idx_medicine_batches_expiry ON medicine_batches(expiry);
+
+CREATE TABLE medicine_ingredients (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    medicine_id text NOT NULL REFERENCES medicines(id),
+    ingredient  text NOT NULL,
+    drug_class  text
+);
+
+CREATE INDEX idx_medicine_ingredients_medicine_id ON medicine_ingredients(medicine_id);
+
+CREATE TABLE alerts (
+    id              integer PRIMARY KEY AUTOINCREMENT,
+    type            text NOT NULL,
+    medicine_id     text NOT NULL,
+    medicine_name   text,
+    batch_id        integer,
+    message         text,
+    status          text NOT NULL,
+    acknowledged_by text,
+    acknowledged_at datetime,
+    resolved_by     text,
+    resolved_at     datetime,
+    created_at      datetime,
+    updated_at      datetime
+);
+
+CREATE INDEX idx_alerts_type ON alerts(type);
+CREATE INDEX idx_alerts_medicine_id ON alerts(medicine_id);
+CREATE INDEX idx_alerts_status ON alerts(status);
+
+CREATE TABLE patients (
+    id                    text PRIMARY KEY,
+    medical_record_number text NOT NULL,
+    nik                   text,
+    name                  text NOT NULL,
+    dob                   text,
+    gender                text,
+    blood_type            text,
+    phone                 text,
+    address               text,
+    allergies             text,
+    created_at            datetime,
+    updated_at            datetime
+);
+
+CREATE UNIQUE INDEX idx_patients_medical_record_number ON patients(medical_record_number);
+CREATE UNIQUE INDEX idx_patients_nik ON patients(nik);
+CREATE INDEX idx_patients_created_at ON patients(created_at DESC);
+
+CREATE TABLE patient_allergens (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    patient_id text NOT NULL REFERENCES patients(id),
+    allergen   text NOT NULL,
+    reaction   text
+);
+
+CREATE INDEX idx_patient_allergens_patient_id ON patient_allergens(patient_id);
+
+CREATE TABLE visits (
+    id            integer PRIMARY KEY AUTOINCREMENT,
+    patient_id    text NOT NULL REFERENCES patients(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    polyclinic    text NOT NULL DEFAULT 'UMUM',
+    queue_date    date,
+    ticket_number integer,
+    ticket        text,
+    priority      text NOT NULL DEFAULT 'regular',
+    status        text NOT NULL,
+    skip_count    integer DEFAULT 0,
+    called_at     datetime,
+    started_at    datetime,
+    closed_at     datetime,
+    created_at    datetime,
+    updated_at    datetime
+);
+
+CREATE INDEX idx_visits_patient_id ON visits(patient_id);
+CREATE INDEX idx_visits_status ON visits(status);
+CREATE UNIQUE INDEX idx_visit_ticket ON visits(queue_date, polyclinic, ticket_number);
+
+CREATE TABLE queue_counters (
+    queue_date  date NOT NULL,
+    polyclinic  text NOT NULL,
+    last_number integer NOT NULL,
+    PRIMARY KEY (queue_date, polyclinic)
+);
+
+CREATE TABLE prescriptions (
+    id           text PRIMARY KEY,
+    patient_id   text REFERENCES patients(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    patient_name text,
+    patient_dob  text,
+    allergies    text,
+    doctor_name  text,
+    date         text,
+    status       text,
+    total_price  integer,
+    created_at   datetime,
+    updated_at   datetime
+);
+
+CREATE INDEX idx_prescriptions_patient_id ON prescriptions(patient_id);
+CREATE INDEX idx_prescriptions_status ON prescriptions(status);
+
+CREATE TABLE prescription_items (
+    id                      integer PRIMARY KEY AUTOINCREMENT,
+    prescription_id         text REFERENCES prescriptions(id),
+    medicine_id             text,
+    name                    text,
+    qty                     integer,
+    price                   integer,
+    signa                   text,
+    allergy_override_reason text
+);
+
+CREATE INDEX idx_prescription_items_prescription_id ON prescription_items(prescription_id);
+
+CREATE TABLE prescription_item_batches (
+    id                   integer PRIMARY KEY AUTOINCREMENT,
+    prescription_item_id integer NOT NULL REFERENCES prescription_items(id),
+    batch_id             integer NOT NULL,
+    lot_number           text,
+    expiry               date,
+    qty                  integer
+);
+
+CREATE INDEX idx_prescription_item_batches_prescription_item_id ON prescription_item_batches(prescription_item_id);
+CREATE INDEX idx_prescription_item_batches_batch_id ON prescription_item_batches(batch_id);
+
+CREATE TABLE prescription_histories (
+    id              integer PRIMARY KEY AUTOINCREMENT,
+    prescription_id text REFERENCES prescriptions(id),
+    from_status     text,
+    status          text,
+    pic             text,
+    note            text,
+    created_at      datetime
+);
+
+CREATE INDEX idx_prescription_histories_prescription_id ON prescription_histories(prescription_id);
+
+CREATE TABLE interaction_rules (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    subject_a  text NOT NULL,
+    subject_b  text NOT NULL,
+    severity   text NOT NULL,
+    message    text,
+    created_at datetime
+);
+
+CREATE TABLE sequences (
+    name       text NOT NULL,
+    period     text NOT NULL,
+    last_value integer NOT NULL,
+    PRIMARY KEY (name, period)
+);
+
+CREATE TABLE logs (
+    id            integer PRIMARY KEY AUTOINCREMENT,
+    date          text,
+    type          text,
+    medicine_name text,
+    qty           integer,
+    ref           text,
+    pic           text,
+    created_at    datetime
+);
+
+CREATE INDEX idx_logs_created_at ON logs(created_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE prescription_items DROP COLUMN price_id;
+DROP TABLE IF EXISTS medicine_prices;
@@ -0,0 +1,17 @@
+CREATE TABLE medicine_prices (
+    id             integer PRIMARY KEY AUTOINCREMENT,
+    medicine_id    text NOT NULL REFERENCES medicines(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    price          integer NOT NULL,
+    effective_from datetime NOT NULL,
+    changed_by     text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_medicine_prices_medicine_id ON medicine_prices(medicine_id);
+
+-- Harga katalog saat ini menjadi baris pertama riwayat
+INSERT INTO medicine_prices (medicine_id, price, effective_from, changed_by)
+SELECT id, COALESCE(price, 0), COALESCE(created_at, CURRENT_TIMESTAMP), 'migration'
+FROM medicines;
+
+-- Tanpa REFERENCES: SQLite tidak bisa DROP COLUMN yang punya foreign key (lihat down)
+ALTER TABLE prescription_items ADD COLUMN price_id integer;
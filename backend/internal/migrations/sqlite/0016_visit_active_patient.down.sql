@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_visits_active_patient;
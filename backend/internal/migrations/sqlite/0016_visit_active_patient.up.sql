@@ -0,0 +1,13 @@
+-- Kunjungan aktif ganda dari sebelum ada index: sisakan yang terbaru per pasien
+UPDATE visits SET status = 'Cancelled', closed_at = CURRENT_TIMESTAMP
+WHERE status IN ('Waiting', 'Called', 'Skipped', 'Examining')
+  AND EXISTS (
+      SELECT 1 FROM visits newer
+      WHERE newer.patient_id = visits.patient_id
+        AND newer.status IN ('Waiting', 'Called', 'Skipped', 'Examining')
+        AND newer.id > visits.id
+  );
+
+-- Satu pasien hanya boleh punya satu kunjungan aktif
+CREATE UNIQUE INDEX IF NOT EXISTS idx_visits_active_patient ON visits(patient_id)
+WHERE status IN ('Waiting', 'Called', 'Skipped', 'Examining');
@@ -0,0 +1,6 @@
+-- Backfill data yang dijalankan aplikasi (bukan SQL) dicatat di sini agar
+-- hanya berjalan sekali
+CREATE TABLE IF NOT EXISTS data_backfills (
+    name   text PRIMARY KEY,
+    ran_at datetime NOT NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE refresh_tokens ADD COLUMN claims text;
+DROP INDEX IF EXISTS idx_refresh_tokens_subject;
+ALTER TABLE refresh_tokens DROP COLUMN subject;
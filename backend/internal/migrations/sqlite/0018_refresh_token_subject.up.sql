@@ -0,0 +1,8 @@
+-- Refresh token mencatat pemiliknya ("admin:1", "hospital_user:3") agar claims
+-- dibangun ulang dari data terbaru saat refresh, bukan dari salinan saat login
+ALTER TABLE refresh_tokens ADD COLUMN subject text NOT NULL DEFAULT '';
+CREATE INDEX idx_refresh_tokens_subject ON refresh_tokens(subject);
+
+-- Token lama tanpa pemilik tidak bisa di-refresh lagi; pengguna login ulang
+UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE subject = '' AND revoked_at IS NULL;
+ALTER TABLE refresh_tokens DROP COLUMN claims;
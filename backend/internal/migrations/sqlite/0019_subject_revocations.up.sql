@@ -0,0 +1,9 @@
+-- Pencabutan semua sesi milik satu subject (mis. setelah ganti password).
+-- Access token subject ini dengan iat <= revoked_before ditolak sampai expires_at.
+CREATE TABLE subject_revocations (
+    subject        text PRIMARY KEY,
+    revoked_before datetime NOT NULL,
+    expires_at     datetime
+);
+
+CREATE INDEX idx_subject_revocations_expires_at ON subject_revocations(expires_at);
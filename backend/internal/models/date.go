@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout adalah format tanggal di API, sama dengan yang dipakai frontend
+const DateLayout = "2006-01-02"
+
+// Date adalah tanggal tanpa jam (kolom DATE). Nilai nol disimpan sebagai NULL
+// dan di-serialize sebagai "" agar kompatibel dengan field string sebelumnya.
+type Date struct {
+	time.Time
+}
+
+// ParseDate mem-parse "YYYY-MM-DD"; string kosong menghasilkan Date nol
+func ParseDate(s string) (Date, error) {
+	if s == "" {
+		return Date{}, nil
+	}
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return Date{t}, nil
+}
+
+// Today mengembalikan tanggal hari ini (waktu lokal server)
+func Today() Date {
+	y, m, d := time.Now().Date()
+	return Date{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(DateLayout)
+}
+
+// StartIn mengembalikan jam 00:00 tanggal d di zona loc, untuk membandingkan
+// tanggal dari API dengan kolom timestamp (mis. created_at)
+func (d Date) StartIn(loc *time.Location) time.Time {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, loc)
+}
+
+// AddDays mengembalikan tanggal n hari setelah d
+func (d Date) AddDays(n int) Date {
+	return Date{d.AddDate(0, 0, n)}
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(*s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// UnmarshalText dipakai saat Date dibaca dari query string
+func (d *Date) UnmarshalText(data []byte) error {
+	parsed, err := ParseDate(string(data))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.Format(DateLayout), nil
+}
+
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Date{}
+		return nil
+	case time.Time:
+		y, m, day := v.Date()
+		*d = Date{time.Date(y, m, day, 0, 0, 0, 0, time.UTC)}
+		return nil
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	}
+	return fmt.Errorf("cannot scan %T into Date", value)
+}
+
+func (d *Date) scanString(s string) error {
+	// Beberapa driver mengembalikan DATE lengkap dengan jam
+	if len(s) > len(DateLayout) {
+		s = s[:len(DateLayout)]
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
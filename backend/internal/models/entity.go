@@ -2,11 +2,12 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Project struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Title       string    `gorm:"not null" json:"title"`
 	Description string    `gorm:"type:text" json:"description"`
 	ImageURL    string    `json:"image_url"`
@@ -17,11 +18,73 @@ type Project struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// BeforeCreate membuat UUID di aplikasi agar tidak bergantung pada
+// gen_random_uuid() PostgreSQL
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
 // --- TAMBAHKAN INI ---
 type Admin struct {
-	ID       uint   `gorm:"primaryKey" json:"id"`
-	Username string `gorm:"unique;not null" json:"username"`
-	Password string `gorm:"not null" json:"-"`
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Username       string     `gorm:"unique;not null" json:"username"`
+	Password       string     `gorm:"not null" json:"-"` // bcrypt hash (baris lama mungkin masih plaintext)
+	FailedAttempts int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+}
+
+// RefreshToken disimpan server-side (hanya hash-nya) agar bisa dirotasi dan dicabut.
+// Semua token hasil rotasi dari satu login berbagi FamilyID.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	FamilyID  string     `gorm:"index;not null" json:"family_id"`
+	Subject   string     `gorm:"index;not null" json:"subject"` // Pemilik token, mis. "hospital_user:3"
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"` // Diisi saat dirotasi atau logout
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RevokedToken adalah daftar jti access token yang sudah dicabut sebelum kedaluwarsa
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey" json:"jti"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
+
+// SubjectRevocation mencabut semua access token milik satu subject yang
+// diterbitkan sebelum RevokedBefore, mis. setelah password diganti
+type SubjectRevocation struct {
+	Subject       string    `gorm:"primaryKey" json:"subject"`
+	RevokedBefore time.Time `gorm:"not null" json:"revoked_before"`
+	ExpiresAt     time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TokenPair dikembalikan oleh login dan refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // detik
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type CreateAdminRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+type ResetPasswordRequest struct {
+	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
 // Struct input request (DTO)
@@ -35,49 +98,279 @@ type CreateProjectRequest struct {
 // ============ DOKTERBUBUNG MODELS ============
 
 type Medicine struct {
-	ID        string    `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Type      string    `json:"type"`
-	Stock     int       `json:"stock"`
-	Price     int       `json:"price"`
-	Expiry    string    `json:"expiry"`
-	Location  string    `json:"location"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                string               `gorm:"primaryKey" json:"id"`
+	Name              string               `gorm:"not null" json:"name"`
+	Type              string               `json:"type"`
+	Stock             int                  `json:"stock"` // Jumlah Qty semua batch, disinkronkan oleh repo
+	Price             int                  `json:"price"`
+	Expiry            Date                 `gorm:"type:date" json:"expiry"` // Expiry batch terdekat yang masih ada stoknya
+	Location          string               `json:"location"`
+	ReorderThreshold  int                  `gorm:"not null;default:10" json:"reorder_threshold"`   // Alert jika Stock <= nilai ini
+	ExpiryWarningDays int                  `gorm:"not null;default:30" json:"expiry_warning_days"` // Alert jika batch kedaluwarsa dalam N hari
+	Batches           []MedicineBatch      `gorm:"foreignKey:MedicineID;references:ID" json:"batches,omitempty"`
+	Ingredients       []MedicineIngredient `gorm:"foreignKey:MedicineID;references:ID" json:"ingredients"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+}
+
+// AfterCreate mencatat harga awal obat sebagai baris pertama riwayat harga,
+// termasuk obat yang dibuat lewat seed
+func (m *Medicine) AfterCreate(tx *gorm.DB) error {
+	return tx.Create(&MedicinePrice{
+		MedicineID:    m.ID,
+		Price:         m.Price,
+		EffectiveFrom: m.CreatedAt,
+	}).Error
+}
+
+// MedicinePrice adalah riwayat harga obat. Baris dengan ID terbesar untuk satu
+// obat adalah harga yang berlaku sekarang; item resep menyimpan ID baris yang
+// dipakai saat resep ditulis.
+type MedicinePrice struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	MedicineID    string    `gorm:"index;not null" json:"medicine_id"`
+	Price         int       `gorm:"not null" json:"price"`
+	EffectiveFrom time.Time `gorm:"not null" json:"effective_from"`
+	ChangedBy     string    `json:"changed_by"`
+}
+
+// MedicineIngredient adalah katalog zat aktif obat beserta golongannya,
+// dipakai untuk cek alergi (misal amoxicillin / penicillin)
+type MedicineIngredient struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	MedicineID string `gorm:"index;not null" json:"medicine_id"`
+	Ingredient string `gorm:"not null" json:"ingredient" validate:"required"`
+	DrugClass  string `json:"drug_class"`
+}
+
+// MedicineBatch adalah satu lot kiriman obat dengan expiry-nya sendiri
+type MedicineBatch struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	MedicineID   string    `gorm:"index;not null" json:"medicine_id"`
+	LotNumber    string    `json:"lot_number"`
+	Expiry       Date      `gorm:"type:date;index" json:"expiry"`
+	Qty          int       `json:"qty"` // Sisa stok batch
+	InitialQty   int       `json:"initial_qty"`
+	Supplier     string    `json:"supplier"`
+	ReceivedDate Date      `gorm:"type:date" json:"received_date"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Jenis alert inventori
+const (
+	AlertTypeLowStock = "LOW_STOCK"
+	AlertTypeExpiring = "EXPIRING"
+	AlertTypeExpired  = "EXPIRED"
+)
+
+// Status alert: Open -> Acknowledged -> Resolved
+const (
+	AlertStatusOpen         = "Open"
+	AlertStatusAcknowledged = "Acknowledged"
+	AlertStatusResolved     = "Resolved"
+)
+
+// Alert dibuat oleh scheduler inventori untuk dashboard logistik
+type Alert struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Type           string     `gorm:"index;not null" json:"type"`
+	MedicineID     string     `gorm:"index;not null" json:"medicine_id"`
+	MedicineName   string     `json:"medicine_name"`
+	BatchID        *uint      `json:"batch_id"` // Hanya untuk alert expiry
+	Message        string     `json:"message"`
+	Status         string     `gorm:"index;not null" json:"status"`
+	AcknowledgedBy string     `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	ResolvedBy     string     `json:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
 type Prescription struct {
-	ID          string             `gorm:"primaryKey" json:"id"`
-	PatientName string             `json:"patient_name"`
-	PatientDob  string             `json:"patient_dob"`
-	Allergies   string             `json:"allergies"`
-	DoctorName  string             `json:"doctor_name"`
-	Date        string             `json:"date"`
-	Status      string             `json:"status"` // Pending, Process, Selesai
-	TotalPrice  int                `json:"total_price"`
-	Items       []PrescriptionItem `gorm:"foreignKey:PrescriptionID;references:ID" json:"items"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
+	ID           string                `gorm:"primaryKey" json:"id"`
+	PatientID    *string               `gorm:"index" json:"patient_id"`
+	Patient      *Patient              `gorm:"foreignKey:PatientID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"-"`
+	PatientName  string                `json:"patient_name"` // Snapshot saat resep dibuat
+	PatientDob   string                `json:"patient_dob"`
+	Allergies    string                `json:"allergies"`
+	DoctorName   string                `json:"doctor_name"`
+	Date         string                `json:"date"`
+	Status       string                `json:"status"` // Lihat konstanta PrescriptionStatus*
+	TotalPrice   int                   `json:"total_price"`
+	Items        []PrescriptionItem    `gorm:"foreignKey:PrescriptionID;references:ID" json:"items"`
+	HistoryLogs  []PrescriptionHistory `gorm:"foreignKey:PrescriptionID;references:ID" json:"history_logs"`
+	Warnings     []string              `gorm:"-" json:"warnings,omitempty"`     // Hanya diisi saat resep dibuat
+	Interactions []InteractionWarning  `gorm:"-" json:"interactions,omitempty"` // Hanya diisi saat resep dibuat
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// Tingkat keparahan interaksi obat
+const (
+	SeverityMinor           = "minor"
+	SeverityModerate        = "moderate"
+	SeverityMajor           = "major"
+	SeverityContraindicated = "contraindicated"
+)
+
+// InteractionRule: kombinasi SubjectA + SubjectB (zat aktif atau golongan obat,
+// case-insensitive, tanpa urutan) menghasilkan peringatan dengan severity tertentu
+type InteractionRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SubjectA  string    `gorm:"not null" json:"subject_a"`
+	SubjectB  string    `gorm:"not null" json:"subject_b"`
+	Severity  string    `gorm:"not null" json:"severity"`
+	Message   string    `gorm:"type:text" json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InteractionWarning adalah hasil evaluasi satu InteractionRule pada sepasang item resep
+type InteractionWarning struct {
+	RuleID    uint   `json:"rule_id"`
+	MedicineA string `json:"medicine_a"`
+	MedicineB string `json:"medicine_b"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// Status resep. Alur normal: Pending -> Verified -> Process -> Ready -> Selesai
+const (
+	PrescriptionStatusPending   = "Pending"
+	PrescriptionStatusVerified  = "Verified"
+	PrescriptionStatusProcess   = "Process"
+	PrescriptionStatusReady     = "Ready"
+	PrescriptionStatusSelesai   = "Selesai"
+	PrescriptionStatusCancelled = "Cancelled"
+	PrescriptionStatusRejected  = "Rejected"
+)
+
+// PrescriptionHistory mencatat setiap perpindahan status resep
+type PrescriptionHistory struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	PrescriptionID string    `gorm:"index" json:"prescription_id"`
+	FromStatus     string    `json:"from_status"`
+	Status         string    `json:"status"`
+	Pic            string    `json:"pic"` // Person in charge
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"time"`
 }
 
 type PrescriptionItem struct {
-	ID             uint   `gorm:"primaryKey" json:"id"`
-	PrescriptionID string `gorm:"index" json:"prescription_id"` // Add index here
-	MedicineID     string `json:"medicine_id"`
-	Name           string `json:"name"`
-	Qty            int    `json:"qty"`
-	Price          int    `json:"price"`
-	Signa          string `json:"signa"`
+	ID                    uint                    `gorm:"primaryKey" json:"id"`
+	PrescriptionID        string                  `gorm:"index" json:"prescription_id"` // Add index here
+	MedicineID            string                  `json:"medicine_id"`
+	Name                  string                  `json:"name"` // Snapshot nama obat saat resep dibuat
+	Qty                   int                     `json:"qty"`
+	Price                 int                     `json:"price"`              // Snapshot harga satuan dari katalog saat resep dibuat
+	PriceID               *uint                   `json:"price_id,omitempty"` // Baris medicine_prices yang dipakai; kosong untuk resep lama
+	Signa                 string                  `json:"signa"`
+	AllergyOverrideReason string                  `json:"allergy_override_reason,omitempty"` // Diisi jika dokter tetap meresepkan meski ada konflik alergi
+	Batches               []PrescriptionItemBatch `gorm:"foreignKey:PrescriptionItemID" json:"batches,omitempty"`
+}
+
+// PrescriptionItemBatch mencatat batch mana yang dipakai saat item resep diproses (FEFO)
+type PrescriptionItemBatch struct {
+	ID                 uint   `gorm:"primaryKey" json:"id"`
+	PrescriptionItemID uint   `gorm:"index;not null" json:"prescription_item_id"`
+	BatchID            uint   `gorm:"index;not null" json:"batch_id"`
+	LotNumber          string `json:"lot_number"`
+	Expiry             Date   `gorm:"type:date" json:"expiry"`
+	Qty                int    `json:"qty"`
 }
 
+// Patient adalah master index pasien yang permanen. Status antrean ada di Visit,
+// jadi data identitas dan alergi tidak hilang saat pasien keluar dari antrean.
 type Patient struct {
-	ID        string    `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Dob       string    `json:"dob"`
-	Status    string    `json:"status"` // Waiting, Examining
-	Allergies string    `json:"allergies"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                  string            `gorm:"primaryKey" json:"id"`
+	MedicalRecordNumber string            `gorm:"uniqueIndex;not null" json:"medical_record_number"`
+	NIK                 *string           `gorm:"uniqueIndex" json:"nik"` // Nomor Induk Kependudukan, opsional
+	Name                string            `gorm:"not null" json:"name"`
+	Dob                 string            `json:"dob"`
+	Gender              string            `json:"gender"`     // L, P
+	BloodType           string            `json:"blood_type"` // A, B, AB, O (+/-)
+	Phone               string            `json:"phone"`
+	Address             string            `gorm:"type:text" json:"address"`
+	Allergies           string            `json:"allergies"` // Teks bebas untuk tampilan
+	Allergens           []PatientAllergen `gorm:"foreignKey:PatientID;references:ID" json:"allergens"`
+	CurrentVisit        *Visit            `gorm:"-" json:"current_visit,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+}
+
+// Status kunjungan / antrean
+const (
+	VisitStatusWaiting   = "Waiting"
+	VisitStatusCalled    = "Called"
+	VisitStatusSkipped   = "Skipped" // Dipanggil tapi tidak hadir, bisa dipanggil ulang
+	VisitStatusExamining = "Examining"
+	VisitStatusDone      = "Done"
+	VisitStatusCancelled = "Cancelled"
+)
+
+// Kelas prioritas antrean, urut dari yang paling didahulukan
+const (
+	PriorityEmergency = "emergency"
+	PriorityElderly   = "elderly"
+	PriorityRegular   = "regular"
+)
+
+// DefaultPolyclinic dipakai jika pendaftaran tidak menyebut poli
+const DefaultPolyclinic = "UMUM"
+
+// Visit adalah satu kunjungan pasien (entri antrean). Keluar dari antrean
+// hanya menutup kunjungan, data pasien tetap ada.
+type Visit struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	PatientID    string     `gorm:"index;not null" json:"patient_id"`
+	Patient      *Patient   `gorm:"foreignKey:PatientID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"patient,omitempty"`
+	Polyclinic   string     `gorm:"not null;default:'UMUM';uniqueIndex:idx_visit_ticket,priority:2" json:"polyclinic"`
+	QueueDate    Date       `gorm:"type:date;uniqueIndex:idx_visit_ticket,priority:1" json:"queue_date"`
+	TicketNumber int        `gorm:"uniqueIndex:idx_visit_ticket,priority:3" json:"ticket_number"` // Reset setiap hari per poli
+	Ticket       string     `json:"ticket"`                                                       // Contoh: UMUM-007
+	Priority     string     `gorm:"not null;default:'regular'" json:"priority"`
+	Status       string     `gorm:"index;not null" json:"status"`
+	SkipCount    int        `gorm:"default:0" json:"skip_count"`
+	CalledAt     *time.Time `json:"called_at"`
+	StartedAt    *time.Time `json:"started_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Dihitung saat membaca antrean
+	Position             int `gorm:"-" json:"position"`
+	EstimatedWaitMinutes int `gorm:"-" json:"estimated_wait_minutes"`
+}
+
+// QueueCounter menyimpan nomor tiket terakhir per hari per poli
+type QueueCounter struct {
+	QueueDate  Date   `gorm:"type:date;primaryKey" json:"queue_date"`
+	Polyclinic string `gorm:"primaryKey" json:"polyclinic"`
+	LastNumber int    `gorm:"not null" json:"last_number"`
+}
+
+// Sequence adalah counter monotonic untuk ID yang mudah dibaca (RSP-20261018-0001).
+// Period berisi bagian tanggal dari format ID sehingga counter reset per periode;
+// kosong untuk sequence yang tidak pernah reset.
+type Sequence struct {
+	Name      string `gorm:"primaryKey" json:"name"`
+	Period    string `gorm:"primaryKey" json:"period"`
+	LastValue int64  `gorm:"not null" json:"last_value"`
+}
+
+// DataBackfill menandai backfill data yang sudah pernah dijalankan
+type DataBackfill struct {
+	Name  string    `gorm:"primaryKey" json:"name"`
+	RanAt time.Time `gorm:"not null" json:"ran_at"`
+}
+
+// PatientAllergen adalah satu alergen terstruktur; dicocokkan (case-insensitive)
+// dengan zat aktif maupun golongan obat
+type PatientAllergen struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	PatientID string `gorm:"index;not null" json:"patient_id"`
+	Allergen  string `gorm:"not null" json:"allergen" validate:"required"`
+	Reaction  string `json:"reaction"`
 }
 
 type Log struct {
@@ -91,29 +384,127 @@ type Log struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// Role akun rumah sakit, sama dengan UserRole di frontend
+const (
+	RoleDoctor     = "doctor"
+	RolePharmacist = "pharmacist"
+	RoleLogistics  = "logistics"
+	RoleFrontDesk  = "front-desk"
+	RoleAdmin      = "admin"
+)
+
+// HospitalUser adalah akun staf DokterBubung (terpisah dari Admin portfolio)
+type HospitalUser struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Username  string    `gorm:"unique;not null" json:"username"`
+	Name      string    `gorm:"not null" json:"name"`
+	Role      string    `gorm:"not null" json:"role"`
+	Password  string    `gorm:"not null" json:"-"` // bcrypt hash
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // DTO Requests
+// Aturan validasi di tag `validate` dijalankan package validation sebelum request
+// masuk service; aturan yang butuh database (mis. medicine_id terdaftar) ada di sana.
+
+type HospitalLoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type CreateHospitalUserRequest struct {
+	Username string `json:"username" validate:"required"`
+	Name     string `json:"name" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=doctor pharmacist logistics front-desk admin"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
 type CreatePrescriptionRequest struct {
-	PatientName string                       `json:"patient_name"`
-	PatientDob  string                       `json:"patient_dob"`
-	Allergies   string                       `json:"allergies"`
-	DoctorName  string                       `json:"doctor_name"`
-	Items       []CreatePrescriptionItemRequest `json:"items"`
+	PatientID   string                          `json:"patient_id"` // Jika kosong, dicari dari patient_name + patient_dob
+	PatientName string                          `json:"patient_name"`
+	PatientDob  string                          `json:"patient_dob" validate:"date"`
+	Allergies   string                          `json:"allergies"`
+	DoctorName  string                          `json:"doctor_name"`
+	Items       []CreatePrescriptionItemRequest `json:"items" validate:"min=1,dive"`
 }
 
+// CreatePrescriptionItemRequest tidak membawa nama dan harga; keduanya diambil
+// dari katalog obat saat resep dibuat (field name/price dari client diabaikan)
 type CreatePrescriptionItemRequest struct {
-	MedicineID string `json:"medicine_id"`
-	Name       string `json:"name"`
-	Qty        int    `json:"qty"`
-	Price      int    `json:"price"`
-	Signa      string `json:"signa"`
+	MedicineID            string `json:"medicine_id" validate:"required"`
+	Qty                   int    `json:"qty" validate:"gt=0"`
+	Signa                 string `json:"signa"`
+	AllergyOverrideReason string `json:"allergy_override_reason"` // Wajib jika item konflik dengan alergi pasien
+}
+
+// CreateMedicineRequest: stok awal dicatat sebagai batch pertama dengan expiry ini
+type CreateMedicineRequest struct {
+	ID                string               `json:"id"` // Kosong = dibuat otomatis
+	Name              string               `json:"name" validate:"required"`
+	Type              string               `json:"type"`
+	Stock             int                  `json:"stock" validate:"gte=0"`
+	Price             int                  `json:"price" validate:"gte=0"`
+	Expiry            string               `json:"expiry" validate:"date"`
+	Location          string               `json:"location"`
+	ReorderThreshold  int                  `json:"reorder_threshold" validate:"gte=0"`   // 0 = default
+	ExpiryWarningDays int                  `json:"expiry_warning_days" validate:"gte=0"` // 0 = default
+	Ingredients       []MedicineIngredient `json:"ingredients" validate:"dive"`
+}
+
+type UpdateIngredientsRequest struct {
+	Ingredients []MedicineIngredient `json:"ingredients" validate:"dive"`
+}
+
+type UpdateAllergensRequest struct {
+	Allergens []PatientAllergen `json:"allergens" validate:"dive"`
 }
 
 type AddPatientRequest struct {
-	Name      string `json:"name"`
-	Dob       string `json:"dob"`
+	Name      string `json:"name" validate:"required"`
+	Dob       string `json:"dob" validate:"required,date,notfuture"`
 	Allergies string `json:"allergies"`
+	NIK       string `json:"nik"`
+	Gender    string `json:"gender"`
+	BloodType string `json:"blood_type"`
+	Phone     string `json:"phone"`
+	Address   string `json:"address"`
+	// RegisterOnly: hanya daftarkan pasien tanpa memasukkannya ke antrean
+	RegisterOnly bool   `json:"register_only"`
+	Polyclinic   string `json:"polyclinic"`
+	Priority     string `json:"priority" validate:"omitempty,oneof=regular elderly emergency"` // kosong = otomatis dari umur
+}
+
+type EnqueueRequest struct {
+	Polyclinic string `json:"polyclinic"`
+	Priority   string `json:"priority" validate:"omitempty,oneof=regular elderly emergency"`
+}
+
+type CallNextRequest struct {
+	Polyclinic string `json:"polyclinic"`
+}
+
+type UpdateVisitStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+type UpdatePrescriptionStatusRequest struct {
+	Note string `json:"note"`
+}
+
+type UpdateAlertSettingsRequest struct {
+	ReorderThreshold  int `json:"reorder_threshold" validate:"gte=0"`
+	ExpiryWarningDays int `json:"expiry_warning_days" validate:"gte=0"`
+}
+
+type UpdatePriceRequest struct {
+	Price int `json:"price" validate:"gte=0"`
 }
 
 type RestockRequest struct {
-	Amount int `json:"amount"`
+	Amount       int    `json:"amount" validate:"gt=0"`
+	LotNumber    string `json:"lot_number"`
+	Expiry       Date   `json:"expiry"`
+	Supplier     string `json:"supplier"`
+	ReceivedDate Date   `json:"received_date" validate:"notfuture"` // Default hari ini
 }
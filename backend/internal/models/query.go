@@ -0,0 +1,74 @@
+package models
+
+// Batas paginasi untuk semua endpoint list. DefaultPageSize hanya dipakai jika
+// klien mengirim page tanpa page_size.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ListQuery berisi paginasi dan urutan yang sama untuk semua endpoint list.
+// Sort memakai nama field, diawali "-" untuk descending (mis. "-created_at").
+// Tanpa page dan page_size semua baris dikembalikan, seperti sebelum ada paginasi.
+type ListQuery struct {
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+	Sort     string `query:"sort"`
+}
+
+// Normalize mengisi nilai default dan membatasi ukuran halaman
+func (q *ListQuery) Normalize() {
+	if q.Unpaged() {
+		return
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize > MaxPageSize {
+		q.PageSize = MaxPageSize
+	}
+}
+
+// Unpaged bernilai true jika klien tidak meminta paginasi sama sekali
+func (q ListQuery) Unpaged() bool {
+	return q.Page == 0 && q.PageSize == 0
+}
+
+// Offset mengembalikan jumlah baris yang dilewati untuk halaman ini
+func (q ListQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+type PrescriptionFilter struct {
+	ListQuery
+	Status    string `query:"status"`
+	Doctor    string `query:"doctor"`
+	PatientID string `query:"patient_id"`
+	DateFrom  Date   `query:"date_from"`
+	DateTo    Date   `query:"date_to"`
+}
+
+type PatientFilter struct {
+	ListQuery
+	Search string `query:"q"` // nama, nomor rekam medis atau NIK
+	Gender string `query:"gender"`
+}
+
+type MedicineFilter struct {
+	ListQuery
+	Type     string `query:"type"`
+	Search   string `query:"q"`
+	LowStock bool   `query:"low_stock"` // stok <= batas reorder
+}
+
+type LogFilter struct {
+	ListQuery
+	Type     string `query:"type"` // IN, OUT
+	Pic      string `query:"pic"`
+	Medicine string `query:"medicine"`
+	DateFrom Date   `query:"date_from"`
+	DateTo   Date   `query:"date_to"`
+}
@@ -1,94 +1,250 @@
 package repository
 
 import (
+	"backend/internal/cache"
 	"backend/internal/models"
-	"sync"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Error dari GORM yang dikenali service layer
+var (
+	ErrNotFound  = gorm.ErrRecordNotFound // getter by-ID, barisnya tidak ada
+	ErrDuplicate = gorm.ErrDuplicatedKey  // insert melanggar unique constraint
 )
 
 type HospitalRepository interface {
+	// Transaction
+	// WithTx menjalankan fn di dalam satu transaksi database. Repo yang diberikan
+	// ke fn terikat ke transaksi tersebut; error dari fn akan me-rollback semuanya.
+	WithTx(fn func(tx HospitalRepository) error) error
+
 	// Medicine
 	GetAllMedicines() ([]models.Medicine, error)
+	ListMedicines(filter models.MedicineFilter) ([]models.Medicine, int64, error)
 	GetMedicineByID(id string) (*models.Medicine, error)
+	GetMedicinesByIDs(ids []string) ([]models.Medicine, error)
+	GetMedicineByIDForUpdate(id string) (*models.Medicine, error)
 	CreateMedicine(medicine *models.Medicine) error
 	UpdateMedicine(medicine *models.Medicine) error
-	RestockMedicine(id string, amount int) error
+	UpdateMedicineAlertSettings(id string, reorderThreshold, expiryWarningDays int) error
+
+	// Medicine price
+	CreateMedicinePrice(price *models.MedicinePrice) error
+	GetMedicinePrices(medicineID string) ([]models.MedicinePrice, error)
+	GetCurrentPrices(medicineIDs []string) ([]models.MedicinePrice, error)
+
+	// Medicine ingredient
+	GetIngredientsByMedicineIDs(medicineIDs []string) ([]models.MedicineIngredient, error)
+	ReplaceMedicineIngredients(medicineID string, ingredients []models.MedicineIngredient) error
+
+	// Interaction rule
+	GetAllInteractionRules() ([]models.InteractionRule, error)
+	ReplaceInteractionRules(rules []models.InteractionRule) error
+
+	// Medicine batch
+	CreateBatch(batch *models.MedicineBatch) error
+	GetAvailableBatches(medicineID string) ([]models.MedicineBatch, error)
+	GetAvailableBatchesForUpdate(medicineID string) ([]models.MedicineBatch, error)
+	ConsumeBatch(batchID uint, qty int) error
+	SyncMedicineStock(medicineID string) error
+	CreatePrescriptionItemBatch(itemBatch *models.PrescriptionItemBatch) error
 
 	// Prescription
-	GetAllPrescriptions() ([]models.Prescription, error)
+	GetAllPrescriptions(filter models.PrescriptionFilter) ([]models.Prescription, int64, error)
 	GetPrescriptionByID(id string) (*models.Prescription, error)
+	GetPrescriptionByIDForUpdate(id string) (*models.Prescription, error)
+	GetPrescriptionsByPatientID(patientID string) ([]models.Prescription, error)
+	GetUnlinkedPrescriptions() ([]models.Prescription, error)
+	LinkPrescriptionPatient(prescriptionID string, patientID string) error
 	CreatePrescription(prescription *models.Prescription) error
 	UpdatePrescriptionStatus(id string, status string) error
+	CreatePrescriptionHistory(history *models.PrescriptionHistory) error
 
 	// Patient
-	GetAllPatients() ([]models.Patient, error)
+	GetAllPatients(filter models.PatientFilter) ([]models.Patient, int64, error)
+	GetPatientByID(id string) (*models.Patient, error)
+	GetPatientByIDForUpdate(id string) (*models.Patient, error)
+	FindPatientsByNameDob(name string, dob string) ([]models.Patient, error)
 	CreatePatient(patient *models.Patient) error
-	DeletePatient(id string) error
+	ReplacePatientAllergens(patientID string, allergens []models.PatientAllergen) error
+	UpdatePatientAllergiesText(patientID string, allergies string) error
+	UpdatePatient(patient *models.Patient) error
+	FindPatientByNIK(nik string) (*models.Patient, error)
+
+	// Visit (antrean)
+	GetActiveVisits(date models.Date, polyclinic string) ([]models.Visit, error)
+	GetNextWaitingVisitForUpdate(date models.Date, polyclinic string) (*models.Visit, error)
+	GetRecentCompletedVisits(polyclinic string, limit int) ([]models.Visit, error)
+	NextTicketNumber(date models.Date, polyclinic string) (int, error)
+
+	GetVisitByID(id uint) (*models.Visit, error)
+	GetVisitByIDForUpdate(id uint) (*models.Visit, error)
+	GetActiveVisitByPatientID(patientID string) (*models.Visit, error)
+	CreateVisit(visit *models.Visit) error
+	UpdateVisit(visit *models.Visit) error
+	CancelStaleVisits(patientID string, before models.Date) error
+
+	// Sequence ID
+	NextSequenceValue(name, period string) (int64, error)
+
+	// Backfill data
+	MarkBackfillRun(name string) (bool, error)
 
 	// Log
-	GetAllLogs() ([]models.Log, error)
+	GetAllLogs(filter models.LogFilter) ([]models.Log, int64, error)
 	CreateLog(log *models.Log) error
+
+	// Alert
+	GetAlerts(status string) ([]models.Alert, error)
+	GetUnresolvedAlerts() ([]models.Alert, error)
+	GetAlertByID(id uint) (*models.Alert, error)
+	CreateAlert(alert *models.Alert) error
+	TransitionAlert(alert *models.Alert, from ...string) (bool, error)
+
+	// User
+	GetUserByUsername(username string) (*models.HospitalUser, error)
+	GetUserByID(id uint) (*models.HospitalUser, error)
+	CreateUser(user *models.HospitalUser) error
+	CountUsers() (int64, error)
 }
 
-type hospitalRepo struct {
-	db *gorm.DB
+// Namespace key cache; invalidasi menghapus seluruh namespace
+const (
+	medicinesCacheKey     = "medicines:"
+	prescriptionsCacheKey = "prescriptions:"
+	patientsCacheKey      = "patients:"
+	logsCacheKey          = "logs:"
+)
 
-	// Medicines cache
-	medicinesCache      []models.Medicine
-	medicinesCacheMutex sync.RWMutex
-	medicinesCacheTime  time.Time
+// CacheTTL adalah umur cache per namespace; nilai 0 memakai DefaultCacheTTL
+type CacheTTL struct {
+	Medicines     time.Duration
+	Prescriptions time.Duration
+	Patients      time.Duration
+	Logs          time.Duration
+}
 
-	// Prescriptions cache
-	prescriptionsCache      []models.Prescription
-	prescriptionsCacheMutex sync.RWMutex
-	prescriptionsCacheTime  time.Time
+var DefaultCacheTTL = CacheTTL{
+	Medicines:     5 * time.Minute,
+	Prescriptions: 2 * time.Minute,
+	Patients:      1 * time.Minute,
+	Logs:          30 * time.Second,
+}
+
+func (t CacheTTL) withDefaults() CacheTTL {
+	if t.Medicines <= 0 {
+		t.Medicines = DefaultCacheTTL.Medicines
+	}
+	if t.Prescriptions <= 0 {
+		t.Prescriptions = DefaultCacheTTL.Prescriptions
+	}
+	if t.Patients <= 0 {
+		t.Patients = DefaultCacheTTL.Patients
+	}
+	if t.Logs <= 0 {
+		t.Logs = DefaultCacheTTL.Logs
+	}
+	return t
+}
 
-	// Patients cache
-	patientsCache      []models.Patient
-	patientsCacheMutex sync.RWMutex
-	patientsCacheTime  time.Time
+type hospitalRepo struct {
+	db    *gorm.DB
+	cache cache.Cache
+	ttl   CacheTTL
+}
 
-	// Logs cache
-	logsCache      []models.Log
-	logsCacheMutex sync.RWMutex
-	logsCacheTime  time.Time
+func NewHospitalRepo(db *gorm.DB, c cache.Cache, ttl CacheTTL) HospitalRepository {
+	if c == nil {
+		c = cache.Noop()
+	}
+	return &hospitalRepo{db: db, cache: c, ttl: ttl.withDefaults()}
 }
 
-func NewHospitalRepo(db *gorm.DB) HospitalRepository {
-	return &hospitalRepo{
-		db: db,
+// ============ TRANSACTION ============
+
+func (r *hospitalRepo) WithTx(fn func(tx HospitalRepository) error) error {
+	// Invalidasi dari dalam transaksi ditahan sampai commit; jika rollback, dibuang
+	deferred := cache.NewDeferred(r.cache)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&hospitalRepo{db: tx, cache: deferred, ttl: r.ttl})
+	})
+	if err == nil {
+		deferred.Flush()
 	}
+	return err
 }
 
 // ============ MEDICINE ============
 
 func (r *hospitalRepo) GetAllMedicines() ([]models.Medicine, error) {
 	// Check cache first
-	r.medicinesCacheMutex.RLock()
-	if len(r.medicinesCache) > 0 && time.Since(r.medicinesCacheTime) < 5*time.Minute {
-		defer r.medicinesCacheMutex.RUnlock()
-		return r.medicinesCache, nil
+	key := cache.Key(medicinesCacheKey + "all")
+	var medicines []models.Medicine
+	if cache.GetJSON(r.cache, key, &medicines) {
+		return medicines, nil
 	}
-	r.medicinesCacheMutex.RUnlock()
 
 	// Cache miss, query database
-	var medicines []models.Medicine
-	err := r.db.Find(&medicines).Error
+	err := r.db.Preload("Batches", availableBatches).Preload("Ingredients").Find(&medicines).Error
 	if err != nil {
 		return nil, err
 	}
 
-	// Update cache
-	r.medicinesCacheMutex.Lock()
-	r.medicinesCache = medicines
-	r.medicinesCacheTime = time.Now()
-	r.medicinesCacheMutex.Unlock()
-
+	cache.SetJSON(r.cache, key, medicines, r.ttl.Medicines)
 	return medicines, nil
 }
 
+// medicineSorts memetakan nama field sort ke kolom yang boleh dipakai
+var medicineSorts = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"type":       "type",
+	"stock":      "stock",
+	"price":      "price",
+	"expiry":     "expiry",
+	"created_at": "created_at",
+}
+
+// ListMedicines mengembalikan satu halaman obat beserta total baris yang cocok dengan filter
+func (r *hospitalRepo) ListMedicines(filter models.MedicineFilter) ([]models.Medicine, int64, error) {
+	filter.Normalize()
+	key := cache.Key(medicinesCacheKey+"list", filter)
+
+	// Check cache first
+	var page cachedPage[models.Medicine]
+	if cache.GetJSON(r.cache, key, &page) {
+		return page.Items, page.Total, nil
+	}
+
+	// Cache miss, query database
+	query := r.db.Model(&models.Medicine{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Search != "" {
+		query = query.Where(likeFilter("name"), likePattern(filter.Search))
+	}
+	if filter.LowStock {
+		query = query.Where("stock <= reorder_threshold")
+	}
+
+	query, total, err := paginate(query, &filter.ListQuery, medicineSorts, "name")
+	if err != nil {
+		return nil, 0, err
+	}
+	var medicines []models.Medicine
+	err = query.Preload("Batches", availableBatches).Preload("Ingredients").Find(&medicines).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cache.SetJSON(r.cache, key, cachedPage[models.Medicine]{Items: medicines, Total: total}, r.ttl.Medicines)
+	return medicines, total, nil
+}
+
 func (r *hospitalRepo) GetMedicineByID(id string) (*models.Medicine, error) {
 	var medicine models.Medicine
 	err := r.db.First(&medicine, "id = ?", id).Error
@@ -98,13 +254,38 @@ func (r *hospitalRepo) GetMedicineByID(id string) (*models.Medicine, error) {
 	return &medicine, nil
 }
 
+// GetMedicinesByIDs memuat obat dengan ID yang ada di ids; ID yang tidak dikenal dilewati
+func (r *hospitalRepo) GetMedicinesByIDs(ids []string) ([]models.Medicine, error) {
+	var medicines []models.Medicine
+	if len(ids) == 0 {
+		return medicines, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&medicines).Error
+	return medicines, err
+}
+
+// availableBatches memuat batch yang masih ada stoknya, urut FEFO
+// (expiry terdekat dulu, batch tanpa expiry paling akhir)
+func availableBatches(db *gorm.DB) *gorm.DB {
+	return db.Where("qty > 0").Order("CASE WHEN expiry IS NULL THEN 1 ELSE 0 END, expiry ASC, id ASC")
+}
+
+// GetMedicineByIDForUpdate mengunci baris obat (SELECT ... FOR UPDATE) sampai
+// transaksi selesai. Hanya bermakna jika dipanggil dari repo di dalam WithTx.
+func (r *hospitalRepo) GetMedicineByIDForUpdate(id string) (*models.Medicine, error) {
+	var medicine models.Medicine
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&medicine, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &medicine, nil
+}
+
 func (r *hospitalRepo) CreateMedicine(medicine *models.Medicine) error {
 	err := r.db.Create(medicine).Error
 	if err == nil {
 		// Invalidate cache
-		r.medicinesCacheMutex.Lock()
-		r.medicinesCache = nil
-		r.medicinesCacheMutex.Unlock()
+		r.cache.DeletePrefix(medicinesCacheKey)
 	}
 	return err
 }
@@ -113,68 +294,278 @@ func (r *hospitalRepo) UpdateMedicine(medicine *models.Medicine) error {
 	err := r.db.Save(medicine).Error
 	if err == nil {
 		// Invalidate cache
-		r.medicinesCacheMutex.Lock()
-		r.medicinesCache = nil
-		r.medicinesCacheMutex.Unlock()
+		r.cache.DeletePrefix(medicinesCacheKey)
 	}
 	return err
 }
 
-func (r *hospitalRepo) RestockMedicine(id string, amount int) error {
-	err := r.db.Model(&models.Medicine{}).Where("id = ?", id).Update("stock", gorm.Expr("stock + ?", amount)).Error
+// UpdateMedicineAlertSettings hanya menulis dua kolom pengaturan alert agar
+// stok yang berubah bersamaan (dispense/restock) tidak tertimpa
+func (r *hospitalRepo) UpdateMedicineAlertSettings(id string, reorderThreshold, expiryWarningDays int) error {
+	result := r.db.Model(&models.Medicine{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"reorder_threshold":   reorderThreshold,
+		"expiry_warning_days": expiryWarningDays,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	// Invalidate cache
+	r.cache.DeletePrefix(medicinesCacheKey)
+	return nil
+}
+
+// ============ MEDICINE PRICE ============
+
+func (r *hospitalRepo) CreateMedicinePrice(price *models.MedicinePrice) error {
+	return r.db.Create(price).Error
+}
+
+// GetMedicinePrices mengembalikan riwayat harga satu obat, terbaru dulu
+func (r *hospitalRepo) GetMedicinePrices(medicineID string) ([]models.MedicinePrice, error) {
+	var prices []models.MedicinePrice
+	err := r.db.Where("medicine_id = ?", medicineID).Order("id DESC").Find(&prices).Error
+	return prices, err
+}
+
+// GetCurrentPrices mengembalikan baris harga terbaru untuk setiap obat di medicineIDs
+func (r *hospitalRepo) GetCurrentPrices(medicineIDs []string) ([]models.MedicinePrice, error) {
+	var prices []models.MedicinePrice
+	if len(medicineIDs) == 0 {
+		return prices, nil
+	}
+	latest := r.db.Model(&models.MedicinePrice{}).Select("MAX(id)").Where("medicine_id IN ?", medicineIDs).Group("medicine_id")
+	err := r.db.Where("id IN (?)", latest).Find(&prices).Error
+	return prices, err
+}
+
+// ============ MEDICINE INGREDIENT ============
+
+func (r *hospitalRepo) GetIngredientsByMedicineIDs(medicineIDs []string) ([]models.MedicineIngredient, error) {
+	var ingredients []models.MedicineIngredient
+	err := r.db.Where("medicine_id IN ?", medicineIDs).Find(&ingredients).Error
+	return ingredients, err
+}
+
+// ReplaceMedicineIngredients mengganti seluruh katalog zat aktif sebuah obat
+// ReplaceMedicineIngredients menghapus lalu menulis ulang daftarnya; panggil dari repo di dalam
+// WithTx agar tidak ada yang melihat daftar kosong di tengah jalan
+func (r *hospitalRepo) ReplaceMedicineIngredients(medicineID string, ingredients []models.MedicineIngredient) error {
+	err := r.db.Where("medicine_id = ?", medicineID).Delete(&models.MedicineIngredient{}).Error
+	if err != nil {
+		return err
+	}
+	if len(ingredients) > 0 {
+		for i := range ingredients {
+			ingredients[i].ID = 0
+			ingredients[i].MedicineID = medicineID
+		}
+		err = r.db.Create(&ingredients).Error
+	}
+	if err == nil {
+		// Invalidate cache
+		r.cache.DeletePrefix(medicinesCacheKey)
+	}
+	return err
+}
+
+// ============ INTERACTION RULE ============
+
+func (r *hospitalRepo) GetAllInteractionRules() ([]models.InteractionRule, error) {
+	var rules []models.InteractionRule
+	err := r.db.Find(&rules).Error
+	return rules, err
+}
+
+// ReplaceInteractionRules mengganti seluruh tabel aturan interaksi.
+// Panggil lewat WithTx agar tabel tidak sempat kosong.
+func (r *hospitalRepo) ReplaceInteractionRules(rules []models.InteractionRule) error {
+	err := r.db.Where("1 = 1").Delete(&models.InteractionRule{}).Error
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	return r.db.Create(&rules).Error
+}
+
+// ============ MEDICINE BATCH ============
+
+func (r *hospitalRepo) CreateBatch(batch *models.MedicineBatch) error {
+	return r.db.Create(batch).Error
+}
+
+// GetAvailableBatches mengembalikan batch yang masih ada stoknya dalam urutan
+// FEFO (expiry terdekat dulu, batch tanpa expiry paling akhir).
+func (r *hospitalRepo) GetAvailableBatches(medicineID string) ([]models.MedicineBatch, error) {
+	var batches []models.MedicineBatch
+	err := availableBatches(r.db.Where("medicine_id = ?", medicineID)).Find(&batches).Error
+	return batches, err
+}
+
+// GetAvailableBatchesForUpdate sama seperti GetAvailableBatches tapi mengunci batch-batchnya
+func (r *hospitalRepo) GetAvailableBatchesForUpdate(medicineID string) ([]models.MedicineBatch, error) {
+	var batches []models.MedicineBatch
+	err := availableBatches(r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("medicine_id = ?", medicineID)).
+		Find(&batches).Error
+	return batches, err
+}
+
+func (r *hospitalRepo) ConsumeBatch(batchID uint, qty int) error {
+	return r.db.Model(&models.MedicineBatch{}).Where("id = ?", batchID).Update("qty", gorm.Expr("qty - ?", qty)).Error
+}
+
+// SyncMedicineStock menghitung ulang Stock dan Expiry obat dari batch-batchnya.
+// Harus dipanggil setiap kali qty batch berubah.
+func (r *hospitalRepo) SyncMedicineStock(medicineID string) error {
+	var result struct {
+		Stock  int
+		Expiry models.Date
+	}
+	err := r.db.Model(&models.MedicineBatch{}).
+		Select("COALESCE(SUM(qty), 0) AS stock, MIN(CASE WHEN qty > 0 THEN expiry END) AS expiry").
+		Where("medicine_id = ?", medicineID).
+		Scan(&result).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Model(&models.Medicine{}).Where("id = ?", medicineID).Updates(map[string]interface{}{
+		"stock":  result.Stock,
+		"expiry": result.Expiry,
+	}).Error
+	if err == nil {
+		// Invalidate cache
+		r.cache.DeletePrefix(medicinesCacheKey)
+	}
+	return err
+}
+
+func (r *hospitalRepo) CreatePrescriptionItemBatch(itemBatch *models.PrescriptionItemBatch) error {
+	err := r.db.Create(itemBatch).Error
 	if err == nil {
 		// Invalidate cache
-		r.medicinesCacheMutex.Lock()
-		r.medicinesCache = nil
-		r.medicinesCacheMutex.Unlock()
+		r.cache.DeletePrefix(prescriptionsCacheKey)
 	}
 	return err
 }
 
 // ============ PRESCRIPTION ============
 
-func (r *hospitalRepo) GetAllPrescriptions() ([]models.Prescription, error) {
+var prescriptionSorts = map[string]string{
+	"id":           "id",
+	"date":         "date",
+	"status":       "status",
+	"patient_name": "patient_name",
+	"doctor_name":  "doctor_name",
+	"total_price":  "total_price",
+	"created_at":   "created_at",
+}
+
+// GetAllPrescriptions mengembalikan satu halaman resep yang cocok dengan filter beserta totalnya
+func (r *hospitalRepo) GetAllPrescriptions(filter models.PrescriptionFilter) ([]models.Prescription, int64, error) {
+	filter.Normalize()
+	key := cache.Key(prescriptionsCacheKey+"list", filter)
+
 	// Check cache first
-	r.prescriptionsCacheMutex.RLock()
-	if len(r.prescriptionsCache) > 0 && time.Since(r.prescriptionsCacheTime) < 2*time.Minute {
-		defer r.prescriptionsCacheMutex.RUnlock()
-		return r.prescriptionsCache, nil
+	var page cachedPage[models.Prescription]
+	if cache.GetJSON(r.cache, key, &page) {
+		return page.Items, page.Total, nil
 	}
-	r.prescriptionsCacheMutex.RUnlock()
 
 	// Cache miss, query database
+	query := r.db.Model(&models.Prescription{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Doctor != "" {
+		query = query.Where(likeFilter("doctor_name"), likePattern(filter.Doctor))
+	}
+	if filter.PatientID != "" {
+		query = query.Where("patient_id = ?", filter.PatientID)
+	}
+	// Kolom date berformat YYYY-MM-DD sehingga bisa dibandingkan sebagai string
+	if !filter.DateFrom.IsZero() {
+		query = query.Where("date >= ?", filter.DateFrom.String())
+	}
+	if !filter.DateTo.IsZero() {
+		query = query.Where("date <= ?", filter.DateTo.String())
+	}
+
+	query, total, err := paginate(query, &filter.ListQuery, prescriptionSorts, "-created_at")
+	if err != nil {
+		return nil, 0, err
+	}
 	var prescriptions []models.Prescription
-	err := r.db.Preload("Items").Find(&prescriptions).Error
+	err = query.Preload("Items.Batches").Preload("HistoryLogs", orderHistory).Find(&prescriptions).Error
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Update cache
-	r.prescriptionsCacheMutex.Lock()
-	r.prescriptionsCache = prescriptions
-	r.prescriptionsCacheTime = time.Now()
-	r.prescriptionsCacheMutex.Unlock()
-
-	return prescriptions, nil
+	cache.SetJSON(r.cache, key, cachedPage[models.Prescription]{Items: prescriptions, Total: total}, r.ttl.Prescriptions)
+	return prescriptions, total, nil
 }
 
 func (r *hospitalRepo) GetPrescriptionByID(id string) (*models.Prescription, error) {
 	var prescription models.Prescription
 	// Use Preload untuk fetch items bersamaan
-	err := r.db.Preload("Items").First(&prescription, "id = ?", id).Error
+	err := r.db.Preload("Items.Batches").Preload("HistoryLogs", orderHistory).First(&prescription, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &prescription, nil
 }
 
+// GetPrescriptionByIDForUpdate mengunci baris resep agar resep yang sama tidak
+// bisa diproses dua kali secara bersamaan.
+func (r *hospitalRepo) GetPrescriptionByIDForUpdate(id string) (*models.Prescription, error) {
+	var prescription models.Prescription
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&prescription, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Where("prescription_id = ?", id).Find(&prescription.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+func (r *hospitalRepo) GetPrescriptionsByPatientID(patientID string) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := r.db.Preload("Items.Batches").Preload("HistoryLogs", orderHistory).
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// GetUnlinkedPrescriptions mengembalikan resep lama yang belum punya patient_id
+func (r *hospitalRepo) GetUnlinkedPrescriptions() ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := r.db.Where("patient_id IS NULL").Order("created_at ASC").Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+func (r *hospitalRepo) LinkPrescriptionPatient(prescriptionID string, patientID string) error {
+	err := r.db.Model(&models.Prescription{}).Where("id = ?", prescriptionID).Update("patient_id", patientID).Error
+	if err == nil {
+		// Invalidate cache
+		r.cache.DeletePrefix(prescriptionsCacheKey)
+	}
+	return err
+}
+
+// orderHistory mengurutkan riwayat status dari yang paling lama
+func orderHistory(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC, id ASC")
+}
+
 func (r *hospitalRepo) CreatePrescription(prescription *models.Prescription) error {
 	err := r.db.Create(prescription).Error
 	if err == nil {
 		// Invalidate cache
-		r.prescriptionsCacheMutex.Lock()
-		r.prescriptionsCache = nil
-		r.prescriptionsCacheMutex.Unlock()
+		r.cache.DeletePrefix(prescriptionsCacheKey)
 	}
 	return err
 }
@@ -183,87 +574,359 @@ func (r *hospitalRepo) UpdatePrescriptionStatus(id string, status string) error
 	err := r.db.Model(&models.Prescription{}).Where("id = ?", id).Update("status", status).Error
 	if err == nil {
 		// Invalidate cache
-		r.prescriptionsCacheMutex.Lock()
-		r.prescriptionsCache = nil
-		r.prescriptionsCacheMutex.Unlock()
+		r.cache.DeletePrefix(prescriptionsCacheKey)
+	}
+	return err
+}
+
+func (r *hospitalRepo) CreatePrescriptionHistory(history *models.PrescriptionHistory) error {
+	err := r.db.Create(history).Error
+	if err == nil {
+		// Invalidate cache
+		r.cache.DeletePrefix(prescriptionsCacheKey)
 	}
 	return err
 }
 
 // ============ PATIENT ============
 
-func (r *hospitalRepo) GetAllPatients() ([]models.Patient, error) {
+var patientSorts = map[string]string{
+	"id":                    "id",
+	"name":                  "name",
+	"dob":                   "dob",
+	"medical_record_number": "medical_record_number",
+	"created_at":            "created_at",
+}
+
+// GetAllPatients mengembalikan satu halaman master pasien yang cocok dengan filter beserta totalnya
+func (r *hospitalRepo) GetAllPatients(filter models.PatientFilter) ([]models.Patient, int64, error) {
+	filter.Normalize()
+	key := cache.Key(patientsCacheKey+"list", filter)
+
 	// Check cache first
-	r.patientsCacheMutex.RLock()
-	if len(r.patientsCache) > 0 && time.Since(r.patientsCacheTime) < 1*time.Minute {
-		defer r.patientsCacheMutex.RUnlock()
-		return r.patientsCache, nil
+	var page cachedPage[models.Patient]
+	if cache.GetJSON(r.cache, key, &page) {
+		return page.Items, page.Total, nil
 	}
-	r.patientsCacheMutex.RUnlock()
 
 	// Cache miss, query database
+	query := r.db.Model(&models.Patient{})
+	if filter.Search != "" {
+		query = query.Where("("+likeFilter("name")+" OR medical_record_number = ? OR nik = ?)",
+			likePattern(filter.Search), filter.Search, filter.Search)
+	}
+	if filter.Gender != "" {
+		query = query.Where("gender = ?", filter.Gender)
+	}
+
+	query, total, err := paginate(query, &filter.ListQuery, patientSorts, "-created_at")
+	if err != nil {
+		return nil, 0, err
+	}
 	var patients []models.Patient
-	err := r.db.Find(&patients).Error
+	err = query.Preload("Allergens").Find(&patients).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cache.SetJSON(r.cache, key, cachedPage[models.Patient]{Items: patients, Total: total}, r.ttl.Patients)
+	return patients, total, nil
+}
+
+func (r *hospitalRepo) GetPatientByID(id string) (*models.Patient, error) {
+	var patient models.Patient
+	err := r.db.Preload("Allergens").First(&patient, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
+	return &patient, nil
+}
 
-	// Update cache
-	r.patientsCacheMutex.Lock()
-	r.patientsCache = patients
-	r.patientsCacheTime = time.Now()
-	r.patientsCacheMutex.Unlock()
+// GetPatientByIDForUpdate mengunci baris pasien sampai transaksi selesai.
+// Allergens tidak dimuat; hanya bermakna jika dipanggil dari repo di dalam WithTx.
+func (r *hospitalRepo) GetPatientByIDForUpdate(id string) (*models.Patient, error) {
+	var patient models.Patient
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&patient, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
 
-	return patients, nil
+// FindPatientsByNameDob mencari pasien dengan nama (tanpa beda huruf besar/kecil dan spasi di ujung) dan tanggal lahir yang sama
+func (r *hospitalRepo) FindPatientsByNameDob(name string, dob string) ([]models.Patient, error) {
+	var patients []models.Patient
+	err := r.db.Preload("Allergens").
+		Where("LOWER(TRIM(name)) = LOWER(TRIM(?)) AND dob = ?", name, dob).
+		Find(&patients).Error
+	return patients, err
 }
 
 func (r *hospitalRepo) CreatePatient(patient *models.Patient) error {
 	err := r.db.Create(patient).Error
 	if err == nil {
 		// Invalidate cache
-		r.patientsCacheMutex.Lock()
-		r.patientsCache = nil
-		r.patientsCacheMutex.Unlock()
+		r.cache.DeletePrefix(patientsCacheKey)
 	}
 	return err
 }
 
-func (r *hospitalRepo) DeletePatient(id string) error {
-	err := r.db.Delete(&models.Patient{}, "id = ?", id).Error
+// ReplacePatientAllergens mengganti seluruh alergen terstruktur seorang pasien
+// ReplacePatientAllergens menghapus lalu menulis ulang daftarnya; panggil dari repo di dalam
+// WithTx agar tidak ada yang melihat daftar kosong di tengah jalan
+func (r *hospitalRepo) ReplacePatientAllergens(patientID string, allergens []models.PatientAllergen) error {
+	err := r.db.Where("patient_id = ?", patientID).Delete(&models.PatientAllergen{}).Error
+	if err != nil {
+		return err
+	}
+	if len(allergens) > 0 {
+		for i := range allergens {
+			allergens[i].ID = 0
+			allergens[i].PatientID = patientID
+		}
+		err = r.db.Create(&allergens).Error
+	}
 	if err == nil {
 		// Invalidate cache
-		r.patientsCacheMutex.Lock()
-		r.patientsCache = nil
-		r.patientsCacheMutex.Unlock()
+		r.cache.DeletePrefix(patientsCacheKey)
 	}
 	return err
 }
 
+func (r *hospitalRepo) UpdatePatientAllergiesText(patientID string, allergies string) error {
+	err := r.db.Model(&models.Patient{}).Where("id = ?", patientID).Update("allergies", allergies).Error
+	if err == nil {
+		// Invalidate cache
+		r.cache.DeletePrefix(patientsCacheKey)
+	}
+	return err
+}
+
+func (r *hospitalRepo) UpdatePatient(patient *models.Patient) error {
+	err := r.db.Omit("Allergens").Save(patient).Error
+	if err == nil {
+		// Invalidate cache
+		r.cache.DeletePrefix(patientsCacheKey)
+	}
+	return err
+}
+
+func (r *hospitalRepo) FindPatientByNIK(nik string) (*models.Patient, error) {
+	var patient models.Patient
+	err := r.db.Preload("Allergens").Where("nik = ?", nik).First(&patient).Error
+	if err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// ============ VISIT ============
+
+// activeVisitStatuses adalah status kunjungan yang masih di antrean
+var activeVisitStatuses = []string{
+	models.VisitStatusWaiting,
+	models.VisitStatusCalled,
+	models.VisitStatusSkipped,
+	models.VisitStatusExamining,
+}
+
+// Urutan antrean: gawat darurat, lansia, lalu reguler; dalam satu kelas urut waktu datang
+const priorityOrder = "CASE priority WHEN 'emergency' THEN 0 WHEN 'elderly' THEN 1 ELSE 2 END"
+
+// Yang sedang dilayani/dipanggil tampil di atas, yang dilewati di bawah
+const visitStatusOrder = "CASE status WHEN 'Examining' THEN 0 WHEN 'Called' THEN 1 WHEN 'Waiting' THEN 2 ELSE 3 END"
+
+// GetActiveVisits mengembalikan antrean pada tanggal date (semua poli jika polyclinic kosong)
+func (r *hospitalRepo) GetActiveVisits(date models.Date, polyclinic string) ([]models.Visit, error) {
+	var visits []models.Visit
+	query := r.db.Preload("Patient.Allergens").Where("queue_date = ? AND status IN ?", date, activeVisitStatuses)
+	if polyclinic != "" {
+		query = query.Where("polyclinic = ?", polyclinic)
+	}
+	err := query.
+		Order(visitStatusOrder).
+		Order(priorityOrder).
+		Order("created_at ASC, id ASC").
+		Find(&visits).Error
+	return visits, err
+}
+
+// GetNextWaitingVisitForUpdate mengunci kunjungan Waiting berikutnya di poli.
+// Baris yang sedang dikunci transaksi lain dilewati agar dua loket tidak
+// memanggil pasien yang sama.
+func (r *hospitalRepo) GetNextWaitingVisitForUpdate(date models.Date, polyclinic string) (*models.Visit, error) {
+	var visit models.Visit
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("queue_date = ? AND status = ? AND polyclinic = ?", date, models.VisitStatusWaiting, polyclinic).
+		Order(priorityOrder).
+		Order("created_at ASC, id ASC").
+		First(&visit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// GetRecentCompletedVisits dipakai untuk menghitung rata-rata lama pelayanan
+func (r *hospitalRepo) GetRecentCompletedVisits(polyclinic string, limit int) ([]models.Visit, error) {
+	var visits []models.Visit
+	err := r.db.
+		Where("status = ? AND polyclinic = ? AND started_at IS NOT NULL AND closed_at IS NOT NULL",
+			models.VisitStatusDone, polyclinic).
+		Order("closed_at DESC").
+		Limit(limit).
+		Find(&visits).Error
+	return visits, err
+}
+
+// NextTicketNumber menaikkan counter tiket poli untuk tanggal tersebut secara atomik
+func (r *hospitalRepo) NextTicketNumber(date models.Date, polyclinic string) (int, error) {
+	counter := models.QueueCounter{QueueDate: date, Polyclinic: polyclinic, LastNumber: 1}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "queue_date"}, {Name: "polyclinic"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"last_number": gorm.Expr("queue_counters.last_number + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "last_number"}}},
+	).Create(&counter).Error
+	if err != nil {
+		return 0, err
+	}
+	return counter.LastNumber, nil
+}
+
+func (r *hospitalRepo) GetVisitByID(id uint) (*models.Visit, error) {
+	var visit models.Visit
+	err := r.db.Preload("Patient").First(&visit, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// GetVisitByIDForUpdate mengunci kunjungan sampai transaksi selesai
+func (r *hospitalRepo) GetVisitByIDForUpdate(id uint) (*models.Visit, error) {
+	var visit models.Visit
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Patient").First(&visit, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+func (r *hospitalRepo) GetActiveVisitByPatientID(patientID string) (*models.Visit, error) {
+	var visit models.Visit
+	err := r.db.Where("patient_id = ? AND status IN ?", patientID, activeVisitStatuses).
+		Order("created_at DESC").
+		First(&visit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+func (r *hospitalRepo) CreateVisit(visit *models.Visit) error {
+	return r.db.Omit("Patient").Create(visit).Error
+}
+
+func (r *hospitalRepo) UpdateVisit(visit *models.Visit) error {
+	return r.db.Omit("Patient").Save(visit).Error
+}
+
+// CancelStaleVisits menutup kunjungan aktif pasien dari antrean hari sebelumnya
+// yang tidak pernah diselesaikan, agar tidak menghalangi kunjungan baru
+func (r *hospitalRepo) CancelStaleVisits(patientID string, before models.Date) error {
+	return r.db.Model(&models.Visit{}).
+		Where("patient_id = ? AND queue_date < ? AND status IN ?", patientID, before, activeVisitStatuses).
+		Updates(map[string]interface{}{
+			"status":    models.VisitStatusCancelled,
+			"closed_at": time.Now(),
+		}).Error
+}
+
+// ============ SEQUENCE ============
+
+// NextSequenceValue menaikkan counter secara atomik. Di dalam transaksi, baris
+// counter terkunci sampai commit sehingga nilai tidak pernah dipakai dua kali.
+func (r *hospitalRepo) NextSequenceValue(name, period string) (int64, error) {
+	sequence := models.Sequence{Name: name, Period: period, LastValue: 1}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}, {Name: "period"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"last_value": gorm.Expr("sequences.last_value + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "last_value"}}},
+	).Create(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+	return sequence.LastValue, nil
+}
+
+// ============ BACKFILL ============
+
+// MarkBackfillRun mencatat backfill name; false jika sudah pernah tercatat.
+// Di dalam transaksi, catatan ikut batal jika backfill gagal.
+func (r *hospitalRepo) MarkBackfillRun(name string) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.DataBackfill{Name: name, RanAt: time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // ============ LOG ============
 
-func (r *hospitalRepo) GetAllLogs() ([]models.Log, error) {
+var logSorts = map[string]string{
+	"created_at":    "created_at",
+	"type":          "type",
+	"medicine_name": "medicine_name",
+	"qty":           "qty",
+	"pic":           "pic",
+}
+
+// GetAllLogs mengembalikan satu halaman log stok yang cocok dengan filter beserta totalnya
+func (r *hospitalRepo) GetAllLogs(filter models.LogFilter) ([]models.Log, int64, error) {
+	filter.Normalize()
+	key := cache.Key(logsCacheKey+"list", filter)
+
 	// Check cache first
-	r.logsCacheMutex.RLock()
-	if len(r.logsCache) > 0 && time.Since(r.logsCacheTime) < 30*time.Second {
-		defer r.logsCacheMutex.RUnlock()
-		return r.logsCache, nil
+	var page cachedPage[models.Log]
+	if cache.GetJSON(r.cache, key, &page) {
+		return page.Items, page.Total, nil
 	}
-	r.logsCacheMutex.RUnlock()
 
 	// Cache miss, query database
-	var logs []models.Log
-	err := r.db.Order("created_at DESC").Limit(100).Find(&logs).Error
-	if err != nil {
-		return nil, err
+	query := r.db.Model(&models.Log{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Pic != "" {
+		query = query.Where(likeFilter("pic"), likePattern(filter.Pic))
+	}
+	if filter.Medicine != "" {
+		query = query.Where(likeFilter("medicine_name"), likePattern(filter.Medicine))
+	}
+	// Tanggal filter dibaca sebagai hari di zona waktu server, bukan UTC
+	if !filter.DateFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.DateFrom.StartIn(time.Local))
+	}
+	if !filter.DateTo.IsZero() {
+		query = query.Where("created_at < ?", filter.DateTo.AddDays(1).StartIn(time.Local))
 	}
 
-	// Update cache
-	r.logsCacheMutex.Lock()
-	r.logsCache = logs
-	r.logsCacheTime = time.Now()
-	r.logsCacheMutex.Unlock()
+	query, total, err := paginate(query, &filter.ListQuery, logSorts, "-created_at")
+	if err != nil {
+		return nil, 0, err
+	}
+	var logs []models.Log
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
 
-	return logs, nil
+	cache.SetJSON(r.cache, key, cachedPage[models.Log]{Items: logs, Total: total}, r.ttl.Logs)
+	return logs, total, nil
 }
 
 func (r *hospitalRepo) CreateLog(log *models.Log) error {
@@ -271,9 +934,79 @@ func (r *hospitalRepo) CreateLog(log *models.Log) error {
 	err := r.db.Create(log).Error
 	if err == nil {
 		// Invalidate cache
-		r.logsCacheMutex.Lock()
-		r.logsCache = nil
-		r.logsCacheMutex.Unlock()
+		r.cache.DeletePrefix(logsCacheKey)
 	}
 	return err
 }
+
+// ============ ALERT ============
+
+// GetAlerts mengembalikan alert terbaru dulu; status kosong berarti semua status
+func (r *hospitalRepo) GetAlerts(status string) ([]models.Alert, error) {
+	var alerts []models.Alert
+	query := r.db.Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *hospitalRepo) GetUnresolvedAlerts() ([]models.Alert, error) {
+	var alerts []models.Alert
+	err := r.db.Where("status <> ?", models.AlertStatusResolved).Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *hospitalRepo) GetAlertByID(id uint) (*models.Alert, error) {
+	var alert models.Alert
+	err := r.db.First(&alert, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *hospitalRepo) CreateAlert(alert *models.Alert) error {
+	return r.db.Create(alert).Error
+}
+
+// TransitionAlert menyimpan status dan kolom ack/resolve alert hanya jika status
+// di database masih salah satu dari from. Mengembalikan false jika request lain
+// (atau scheduler di replica lain) sudah lebih dulu mengubahnya.
+func (r *hospitalRepo) TransitionAlert(alert *models.Alert, from ...string) (bool, error) {
+	result := r.db.Model(alert).Where("status IN ?", from).Select(
+		"status", "acknowledged_by", "acknowledged_at", "resolved_by", "resolved_at", "updated_at",
+	).Updates(alert)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ============ USER ============
+
+func (r *hospitalRepo) GetUserByUsername(username string) (*models.HospitalUser, error) {
+	var user models.HospitalUser
+	err := r.db.Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *hospitalRepo) GetUserByID(id uint) (*models.HospitalUser, error) {
+	var user models.HospitalUser
+	err := r.db.First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *hospitalRepo) CreateUser(user *models.HospitalUser) error {
+	return r.db.Create(user).Error
+}
+
+func (r *hospitalRepo) CountUsers() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.HospitalUser{}).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSort dikembalikan jika parameter sort bukan field yang diizinkan
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// cachedPage adalah satu halaman hasil list beserta totalnya, disimpan di cache
+type cachedPage[T any] struct {
+	Items []T   `json:"items"`
+	Total int64 `json:"total"`
+}
+
+// paginate menghitung total baris yang cocok lalu menambahkan ORDER BY, LIMIT dan
+// OFFSET ke query. Kolom sort hanya diambil dari whitelist agar aman dari injeksi.
+func paginate(query *gorm.DB, q *models.ListQuery, sortable map[string]string, defaultSort string) (*gorm.DB, int64, error) {
+	q.Normalize()
+
+	sort := q.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+	column, ok := sortable[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvalidSort, sort)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// id sebagai tie-breaker agar urutan antar halaman stabil
+	paged := query.Order(column + " " + direction).Order("id " + direction)
+	if !q.Unpaged() {
+		paged = paged.Limit(q.PageSize).Offset(q.Offset())
+	}
+	return paged, total, nil
+}
+
+// likeFilter membuat kondisi pencarian sebagian yang case-insensitive; pasangkan dengan likePattern
+func likeFilter(column string) string {
+	return "LOWER(" + column + ") LIKE ? ESCAPE '\\'"
+}
+
+// likePattern meng-escape wildcard dari input lalu membungkusnya dengan %
+func likePattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + strings.ToLower(replacer.Replace(strings.TrimSpace(s))) + "%"
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"backend/internal/models"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProjectRepository interface {
@@ -12,6 +15,14 @@ type ProjectRepository interface {
 	Delete(id string) error
 	// Tambahan untuk Auth
 	GetAdminByUsername(username string) (models.Admin, error)
+	GetAdminByID(id uint) (models.Admin, error)
+	CreateAdmin(admin *models.Admin) error
+	UpdateAdmin(admin *models.Admin) error
+	IncrementFailedAttempts(id uint) (int, error)
+	LockAdmin(id uint, until time.Time) error
+	ResetFailedAttempts(id uint) error
+	UpdateAdminPassword(id uint, hash string) error
+	ResetAdminPassword(id uint, hash string) error
 }
 
 type projectRepo struct {
@@ -48,3 +59,56 @@ func (r *projectRepo) GetAdminByUsername(username string) (models.Admin, error)
 	err := r.db.Where("username = ?", username).First(&admin).Error
 	return admin, err
 }
+
+func (r *projectRepo) GetAdminByID(id uint) (models.Admin, error) {
+	var admin models.Admin
+	err := r.db.First(&admin, id).Error
+	return admin, err
+}
+
+func (r *projectRepo) CreateAdmin(admin *models.Admin) error {
+	return r.db.Create(admin).Error
+}
+
+func (r *projectRepo) UpdateAdmin(admin *models.Admin) error {
+	return r.db.Save(admin).Error
+}
+
+// IncrementFailedAttempts menaikkan counter login gagal secara atomik dan
+// mengembalikan nilai barunya, agar login gagal yang bersamaan tidak hilang
+func (r *projectRepo) IncrementFailedAttempts(id uint) (int, error) {
+	var admin models.Admin
+	err := r.db.Model(&admin).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_attempts"}}}).
+		Where("id = ?", id).
+		UpdateColumn("failed_attempts", gorm.Expr("failed_attempts + 1")).Error
+	return admin.FailedAttempts, err
+}
+
+// LockAdmin mengunci akun sampai until dan memulai counter dari nol lagi
+func (r *projectRepo) LockAdmin(id uint, until time.Time) error {
+	return r.db.Model(&models.Admin{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_attempts": 0,
+		"locked_until":    until,
+	}).Error
+}
+
+func (r *projectRepo) ResetFailedAttempts(id uint) error {
+	return r.db.Model(&models.Admin{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	}).Error
+}
+
+func (r *projectRepo) UpdateAdminPassword(id uint, hash string) error {
+	return r.db.Model(&models.Admin{}).Where("id = ?", id).Update("password", hash).Error
+}
+
+// ResetAdminPassword mengganti password sekaligus membuka kunci akun
+func (r *projectRepo) ResetAdminPassword(id uint, hash string) error {
+	return r.db.Model(&models.Admin{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":        hash,
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	}).Error
+}
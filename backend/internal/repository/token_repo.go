@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepository interface {
+	// Refresh token
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshTokenByHash(hash string) (*models.RefreshToken, error)
+	ClaimRefreshToken(id uint) (bool, error)
+	RevokeRefreshFamily(familyID string) error
+	RevokeSubjectRefreshTokens(subject string) error
+
+	// Access token revocation list
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	RevokeSubject(subject string, revokedBefore, expiresAt time.Time) error
+	GetSubjectRevocation(subject string) (*models.SubjectRevocation, error)
+	DeleteExpiredTokens() error
+}
+
+type tokenRepo struct {
+	db *gorm.DB
+}
+
+func NewTokenRepo(db *gorm.DB) TokenRepository {
+	return &tokenRepo{db}
+}
+
+func (r *tokenRepo) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *tokenRepo) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ClaimRefreshToken menandai refresh token sudah dipakai. Mengembalikan false jika
+// token sudah lebih dulu dipakai/dicabut oleh request lain.
+func (r *tokenRepo) ClaimRefreshToken(id uint) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *tokenRepo) RevokeRefreshFamily(familyID string) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeSubjectRefreshTokens mencabut semua refresh token aktif milik subject
+func (r *tokenRepo) RevokeSubjectRefreshTokens(subject string) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("subject = ? AND revoked_at IS NULL", subject).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *tokenRepo) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	// Idempotent: logout dua kali dengan token yang sama tidak error
+	return r.db.Where(models.RevokedToken{JTI: jti}).
+		Attrs(models.RevokedToken{ExpiresAt: expiresAt}).
+		FirstOrCreate(&models.RevokedToken{}).Error
+}
+
+func (r *tokenRepo) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeSubject menyimpan (atau memajukan) batas waktu pencabutan access token milik subject
+func (r *tokenRepo) RevokeSubject(subject string, revokedBefore, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at"}),
+	}).Create(&models.SubjectRevocation{Subject: subject, RevokedBefore: revokedBefore, ExpiresAt: expiresAt}).Error
+}
+
+func (r *tokenRepo) GetSubjectRevocation(subject string) (*models.SubjectRevocation, error) {
+	var revocation models.SubjectRevocation
+	err := r.db.Where("subject = ?", subject).First(&revocation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revocation, nil
+}
+
+// DeleteExpiredTokens membersihkan baris yang sudah tidak mungkin dipakai lagi
+func (r *tokenRepo) DeleteExpiredTokens() error {
+	now := time.Now()
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.SubjectRevocation{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// AlertScheduler menjalankan EvaluateInventoryAlerts secara berkala di background
+type AlertScheduler struct {
+	service  HospitalService
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewAlertScheduler(service HospitalService, interval time.Duration) *AlertScheduler {
+	return &AlertScheduler{
+		service:  service,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start langsung mengevaluasi sekali, lalu setiap interval
+func (s *AlertScheduler) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.evaluate()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop menghentikan scheduler dan menunggu evaluasi yang sedang berjalan selesai
+func (s *AlertScheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *AlertScheduler) evaluate() {
+	if err := s.service.EvaluateInventoryAlerts(); err != nil {
+		log.Println("Inventory alert evaluation failed: ", err)
+	}
+}
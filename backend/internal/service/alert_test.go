@@ -0,0 +1,67 @@
+package services
+
+import (
+	"backend/internal/models"
+	"sync"
+	"testing"
+)
+
+func TestEvaluateInventoryAlertsConcurrentCreatesOneAlertPerCondition(t *testing.T) {
+	s, repo := newTestService(t)
+	createTestMedicine(t, s, "Amoxicillin", 2, "")
+
+	// Seolah-olah scheduler di beberapa replica berjalan bersamaan
+	const replicas = 5
+	var wg sync.WaitGroup
+	errs := make([]error, replicas)
+	for i := 0; i < replicas; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.EvaluateInventoryAlerts()
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("EvaluateInventoryAlerts: %v", err)
+		}
+	}
+
+	alerts, err := repo.GetUnresolvedAlerts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0].Type != models.AlertTypeLowStock {
+		t.Errorf("unresolved alerts = %+v, want one low stock alert", alerts)
+	}
+}
+
+func TestResolveAlertRefusedWhileConditionActive(t *testing.T) {
+	s, repo := newTestService(t)
+	medicine := createTestMedicine(t, s, "Amoxicillin", 2, "")
+	if err := s.EvaluateInventoryAlerts(); err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := repo.GetUnresolvedAlerts()
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("unresolved alerts = %v, %v, want one", alerts, err)
+	}
+
+	_, err = s.ResolveAlert(alerts[0].ID, "tester")
+	if de, ok := AsDomainError(err); !ok || de.Code() != "alert_condition_active" {
+		t.Fatalf("ResolveAlert error = %v, want alert_condition_active", err)
+	}
+
+	// Setelah restock kondisinya hilang dan alert boleh ditutup manual
+	if err := s.RestockMedicine(medicine.ID, &models.RestockRequest{Amount: 100, LotNumber: "L1"}, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := s.ResolveAlert(alerts[0].ID, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Status != models.AlertStatusResolved || resolved.ResolvedBy != "tester" {
+		t.Errorf("alert = %+v, want resolved by tester", resolved)
+	}
+}
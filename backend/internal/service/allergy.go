@@ -0,0 +1,66 @@
+package services
+
+import (
+	"backend/internal/models"
+	"strings"
+)
+
+// AllergyConflict menjelaskan satu item resep yang bentrok dengan alergi pasien
+type AllergyConflict struct {
+	MedicineID   string `json:"medicine_id"`
+	MedicineName string `json:"medicine_name"`
+	Allergen     string `json:"allergen"`
+	MatchedOn    string `json:"matched_on"` // Zat aktif atau golongan yang cocok
+	Overridden   bool   `json:"overridden"` // Dokter sudah mengisi allergy_override_reason
+}
+
+// parseAllergies memecah teks alergi bebas ("Penicillin, Seafood") menjadi
+// daftar alergen. "-" dan string kosong berarti tidak ada alergi.
+func parseAllergies(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '/' || r == '\n'
+	})
+
+	allergens := []string{}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" || field == "-" {
+			continue
+		}
+		allergens = append(allergens, field)
+	}
+	return allergens
+}
+
+// findAllergyConflicts mencocokkan setiap alergen dengan zat aktif dan golongan
+// obat pada item resep (case-insensitive)
+func findAllergyConflicts(allergens []string, items []models.PrescriptionItem, ingredients []models.MedicineIngredient) []AllergyConflict {
+	byMedicine := map[string][]models.MedicineIngredient{}
+	for _, ingredient := range ingredients {
+		byMedicine[ingredient.MedicineID] = append(byMedicine[ingredient.MedicineID], ingredient)
+	}
+
+	conflicts := []AllergyConflict{}
+	for _, item := range items {
+		for _, allergen := range allergens {
+			for _, ingredient := range byMedicine[item.MedicineID] {
+				matched := ""
+				if strings.EqualFold(allergen, ingredient.Ingredient) {
+					matched = ingredient.Ingredient
+				} else if ingredient.DrugClass != "" && strings.EqualFold(allergen, ingredient.DrugClass) {
+					matched = ingredient.DrugClass
+				}
+				if matched == "" {
+					continue
+				}
+				conflicts = append(conflicts, AllergyConflict{
+					MedicineID:   item.MedicineID,
+					MedicineName: item.Name,
+					Allergen:     allergen,
+					MatchedOn:    matched,
+				})
+			}
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/migrations"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// newTestDB membuat database SQLite baru yang sudah dimigrasi. File (bukan
+// :memory:) dipakai agar transaksi bersamaan benar-benar memakai koneksi yang berbeda.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	cfg := database.Config{Driver: database.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")}
+	if err := cfg.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(sqlDB, cfg.Driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestService membuat hospitalService di atas newTestDB
+func newTestService(t *testing.T) (*hospitalService, repository.HospitalRepository) {
+	t.Helper()
+	db := newTestDB(t)
+	repo := repository.NewHospitalRepo(db, nil, repository.CacheTTL{})
+	tokens := NewTokenService(repository.NewTokenRepo(db), TokenConfig{Secret: "test"})
+	service := NewHospitalService(repo, tokens, NewEventBus(), HospitalConfig{}).(*hospitalService)
+	return service, repo
+}
+
+func createTestMedicine(t *testing.T, s *hospitalService, name string, stock int, expiry string) *models.Medicine {
+	t.Helper()
+	medicine, err := s.CreateMedicine(&models.CreateMedicineRequest{Name: name, Stock: stock, Price: 1000, Expiry: expiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return medicine
+}
+
+func createTestPatient(t *testing.T, s *hospitalService) *models.Patient {
+	t.Helper()
+	patient, _, err := s.AddPatient(&models.AddPatientRequest{Name: "Budi", Dob: "1990-01-01", RegisterOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patient
+}
+
+// createVerifiedPrescription membuat resep lalu memverifikasinya sehingga siap diproses
+func createVerifiedPrescription(t *testing.T, s *hospitalService, patientID string, items ...models.CreatePrescriptionItemRequest) *models.Prescription {
+	t.Helper()
+	prescription, err := s.CreatePrescription(&models.CreatePrescriptionRequest{PatientID: patientID, Items: items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TransitionPrescription(prescription.ID, models.PrescriptionStatusVerified, "tester", ""); err != nil {
+		t.Fatal(err)
+	}
+	return prescription
+}
+
+func medicineStock(t *testing.T, repo repository.HospitalRepository, id string) int {
+	t.Helper()
+	medicine, err := repo.GetMedicineByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return medicine.Stock
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind mengelompokkan error domain; handler HTTP memetakannya ke status code
+type Kind int
+
+const (
+	KindInternal        Kind = iota // 500
+	KindValidation                  // 400
+	KindUnauthorized                // 401
+	KindForbidden                   // 403
+	KindNotFound                    // 404
+	KindConflict                    // 409
+	KindUnprocessable               // 422
+	KindTooManyRequests             // 429
+)
+
+// DomainError diimplementasikan semua error service yang punya kind dan kode
+// yang bisa dibaca mesin. Details (boleh nil) ikut dikirim ke client.
+type DomainError interface {
+	error
+	Kind() Kind
+	Code() string
+	Details() map[string]interface{}
+}
+
+// Error adalah error domain tanpa detail tambahan. Cause (jika ada) tetap bisa
+// dicek dengan errors.Is.
+type Error struct {
+	kind  Kind
+	code  string
+	msg   string
+	cause error
+}
+
+func (e *Error) Error() string                   { return e.msg }
+func (e *Error) Kind() Kind                      { return e.kind }
+func (e *Error) Code() string                    { return e.code }
+func (e *Error) Details() map[string]interface{} { return nil }
+func (e *Error) Unwrap() error                   { return e.cause }
+
+func newError(kind Kind, code, format string, args ...interface{}) *Error {
+	return &Error{kind: kind, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// Validation untuk input yang salah (400)
+func Validation(code, format string, args ...interface{}) *Error {
+	return newError(KindValidation, code, format, args...)
+}
+
+// Unauthorized untuk kredensial atau token yang tidak valid (401)
+func Unauthorized(code, format string, args ...interface{}) *Error {
+	return newError(KindUnauthorized, code, format, args...)
+}
+
+// NotFound untuk resource yang dituju langsung lewat ID tapi tidak ada (404)
+func NotFound(code, format string, args ...interface{}) *Error {
+	return newError(KindNotFound, code, format, args...)
+}
+
+// Conflict untuk aksi yang bentrok dengan state resource saat ini (409)
+func Conflict(code, format string, args ...interface{}) *Error {
+	return newError(KindConflict, code, format, args...)
+}
+
+// unprocessable membungkus err sebagai 422 dengan kode yang sama, dipakai saat
+// resource yang tidak ada adalah referensi di dalam body, bukan target URL
+func unprocessable(err error) error {
+	code := "unprocessable"
+	var de DomainError
+	if errors.As(err, &de) {
+		code = de.Code()
+	}
+	return &Error{kind: KindUnprocessable, code: code, msg: err.Error(), cause: err}
+}
+
+// notFoundOr mengubah repository.ErrNotFound menjadi NotFound dengan kode code,
+// error lain dikembalikan apa adanya
+func notFoundOr(err error, code, format string, args ...interface{}) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return NotFound(code, format, args...)
+	}
+	return err
+}
+
+// AsDomainError mencari DomainError di rantai err. Error dari repository yang
+// lolos tanpa dibungkus (record not found, duplikat, sort tidak dikenal) ikut dikenali.
+func AsDomainError(err error) (DomainError, bool) {
+	var de DomainError
+	switch {
+	case errors.As(err, &de):
+		return de, true
+	case errors.Is(err, repository.ErrNotFound):
+		return NotFound("not_found", "record not found"), true
+	case errors.Is(err, repository.ErrDuplicate):
+		return Conflict("duplicate", "record already exists"), true
+	case errors.Is(err, repository.ErrInvalidSort):
+		return Validation("invalid_sort", "%s", err.Error()), true
+	}
+	return nil, false
+}
+
+// ErrInvalidSort dikembalikan endpoint list untuk parameter sort yang tidak dikenal
+var ErrInvalidSort = repository.ErrInvalidSort
+
+// ErrPatientNotFound dikembalikan saat resep tidak bisa dihubungkan ke pasien terdaftar
+var ErrPatientNotFound = NotFound("patient_not_found", "patient not found")
+
+// Error antrean
+var (
+	ErrNotInQueue             = NotFound("not_in_queue", "patient is not in the queue")
+	ErrAlreadyQueued          = Conflict("already_queued", "patient is already in the queue")
+	ErrInvalidVisitTransition = Conflict("invalid_visit_transition", "invalid visit status transition")
+	ErrQueueEmpty             = NotFound("queue_empty", "no patient waiting in the queue")
+	ErrInvalidPriority        = Validation("invalid_priority", "priority must be regular, elderly or emergency")
+)
+
+// ErrInvalidCredentials dikembalikan login dengan username atau password yang salah
+var ErrInvalidCredentials = Unauthorized("invalid_credentials", "invalid credentials")
+
+// InsufficientStockError dikembalikan saat stok obat lebih kecil dari qty resep
+type InsufficientStockError struct {
+	MedicineID   string
+	MedicineName string
+	Available    int
+	Requested    int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %s (available %d, requested %d)",
+		e.MedicineName, e.Available, e.Requested)
+}
+
+func (e *InsufficientStockError) Kind() Kind   { return KindConflict }
+func (e *InsufficientStockError) Code() string { return "insufficient_stock" }
+func (e *InsufficientStockError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"medicine_id":   e.MedicineID,
+		"medicine_name": e.MedicineName,
+		"available":     e.Available,
+		"requested":     e.Requested,
+	}
+}
+
+// ExpiredStockError dikembalikan saat stok yang belum kedaluwarsa tidak cukup
+// untuk menyerahkan obat. Expiry adalah tanggal batch terdekat yang ditolak.
+type ExpiredStockError struct {
+	MedicineID   string
+	MedicineName string
+	Expiry       models.Date
+	Usable       int
+	Requested    int
+}
+
+func (e *ExpiredStockError) Error() string {
+	return fmt.Sprintf("cannot dispense %s: stock expires on %s (usable %d, requested %d)",
+		e.MedicineName, e.Expiry, e.Usable, e.Requested)
+}
+
+func (e *ExpiredStockError) Kind() Kind   { return KindConflict }
+func (e *ExpiredStockError) Code() string { return "expired_stock" }
+func (e *ExpiredStockError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"medicine_id":   e.MedicineID,
+		"medicine_name": e.MedicineName,
+		"expiry":        e.Expiry,
+		"usable":        e.Usable,
+		"requested":     e.Requested,
+	}
+}
+
+// PatientNIKConflictError dikembalikan saat NIK sudah terdaftar untuk pasien
+// dengan nama atau tanggal lahir yang berbeda dari request
+type PatientNIKConflictError struct {
+	NIK       string
+	PatientID string
+	Fields    []string
+}
+
+func (e *PatientNIKConflictError) Error() string {
+	return fmt.Sprintf("NIK %s is already registered to patient %s with a different %s",
+		e.NIK, e.PatientID, strings.Join(e.Fields, " and "))
+}
+
+func (e *PatientNIKConflictError) Kind() Kind   { return KindConflict }
+func (e *PatientNIKConflictError) Code() string { return "patient_nik_conflict" }
+func (e *PatientNIKConflictError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"patient_id": e.PatientID,
+		"fields":     e.Fields,
+	}
+}
+
+// AllergyConflictError dikembalikan saat item resep bentrok dengan alergi pasien
+// dan dokter belum mengisi allergy_override_reason untuk item tersebut
+type AllergyConflictError struct {
+	Conflicts []AllergyConflict
+}
+
+func (e *AllergyConflictError) Error() string {
+	c := e.Conflicts[0]
+	msg := fmt.Sprintf("%s conflicts with patient allergy %s", c.MedicineName, c.Allergen)
+	if len(e.Conflicts) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Conflicts)-1)
+	}
+	return msg + "; set allergy_override_reason to prescribe anyway"
+}
+
+func (e *AllergyConflictError) Kind() Kind   { return KindUnprocessable }
+func (e *AllergyConflictError) Code() string { return "allergy_conflict" }
+func (e *AllergyConflictError) Details() map[string]interface{} {
+	return map[string]interface{}{"conflicts": e.Conflicts}
+}
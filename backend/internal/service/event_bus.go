@@ -0,0 +1,108 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Topik event yang bisa dipilih klien lewat ?topics=
+const (
+	TopicMedicines     = "medicines"
+	TopicPrescriptions = "prescriptions"
+	TopicPatients      = "patients"
+	TopicQueue         = "queue"
+	TopicAlerts        = "alerts"
+)
+
+// Buffer per subscriber; klien yang terlalu lambat akan kehilangan event
+// (dan sebaiknya memuat ulang datanya) daripada menahan publisher
+const subscriberBuffer = 64
+
+// Event adalah satu perubahan data yang dikirim ke klien real-time
+type Event struct {
+	Topic string      `json:"topic"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	Time  time.Time   `json:"time"`
+}
+
+// EventBus menyebarkan event mutasi ke semua subscriber di instance ini
+type EventBus interface {
+	Publish(topic, eventType string, data interface{})
+	// Subscribe mengembalikan channel event untuk topik yang diminta (kosong = semua)
+	// dan fungsi untuk berhenti berlangganan
+	Subscribe(topics []string) (<-chan Event, func())
+	// Close menutup channel semua subscriber (stream SSE ikut selesai) dan
+	// menolak subscriber baru; dipanggil saat server shutdown
+	Close()
+}
+
+type subscriber struct {
+	ch     chan Event
+	topics map[string]bool
+	once   sync.Once
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+type eventBus struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+func NewEventBus() EventBus {
+	return &eventBus{subscribers: map[*subscriber]struct{}{}}
+}
+
+func (b *eventBus) Publish(topic, eventType string, data interface{}) {
+	event := Event{Topic: topic, Type: eventType, Data: data, Time: time.Now()}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if len(sub.topics) > 0 && !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+func (b *eventBus) Subscribe(topics []string) (<-chan Event, func()) {
+	sub := &subscriber{ch: make(chan Event, subscriberBuffer), topics: map[string]bool{}}
+	for _, topic := range topics {
+		if topic != "" {
+			sub.topics[topic] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.close()
+		return sub.ch, func() {}
+	}
+	b.subscribers[sub] = struct{}{}
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, sub)
+		b.mu.Unlock()
+		sub.close()
+	}
+}
+
+func (b *eventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		sub.close()
+		delete(b.subscribers, sub)
+	}
+}
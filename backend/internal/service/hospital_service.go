@@ -3,239 +3,1300 @@ package services
 import (
 	"backend/internal/models"
 	"backend/internal/repository"
+	"errors"
 	"fmt"
-	"math/rand"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type HospitalService interface {
 	// Medicine
-	GetAllMedicines() ([]models.Medicine, error)
-	CreateMedicine(medicine *models.Medicine) error
-	RestockMedicine(id string, amount int, pic string) error
+	GetAllMedicines(filter models.MedicineFilter) ([]models.Medicine, int64, error)
+	CreateMedicine(req *models.CreateMedicineRequest) (*models.Medicine, error)
+	RestockMedicine(id string, req *models.RestockRequest, pic string) error
+	UpdateMedicineIngredients(id string, req *models.UpdateIngredientsRequest) (*models.Medicine, error)
+	UpdateMedicinePrice(id string, req *models.UpdatePriceRequest, pic string) (*models.Medicine, error)
+	GetMedicinePrices(id string) ([]models.MedicinePrice, error)
 
 	// Prescription
-	GetAllPrescriptions() ([]models.Prescription, error)
+	GetAllPrescriptions(filter models.PrescriptionFilter) ([]models.Prescription, int64, error)
 	CreatePrescription(req *models.CreatePrescriptionRequest) (*models.Prescription, error)
-	ProcessPrescription(id string) error
-	FinishPrescription(id string) error
+	CheckPrescription(req *models.CreatePrescriptionRequest) (*PrescriptionCheck, error)
+	LoadInteractionRules(path string) (int, error)
+	GetPatientPrescriptions(patientID string) ([]models.Prescription, error)
+	BackfillPrescriptionPatients() (*PatientBackfillReport, error)
+	ProcessPrescription(id string, pic string, note string) error
+	TransitionPrescription(id string, status string, pic string, note string) error
 
 	// Patient
-	GetAllPatients() ([]models.Patient, error)
-	AddPatient(req *models.AddPatientRequest) (*models.Patient, error)
+	GetAllPatients(filter models.PatientFilter) ([]models.Patient, int64, error)
+	// AddPatient mengembalikan created=false jika NIK sudah terdaftar dan pasien lama yang dipakai
+	AddPatient(req *models.AddPatientRequest) (patient *models.Patient, created bool, err error)
+	UpdatePatientAllergens(id string, req *models.UpdateAllergensRequest) (*models.Patient, error)
 	RemovePatient(id string) error
 
+	// Visit (antrean)
+	GetQueue(polyclinic string) ([]models.Visit, error)
+	EnqueuePatient(patientID string, req *models.EnqueueRequest) (*models.Visit, error)
+	CallNextPatient(polyclinic string) (*models.Visit, error)
+	SkipVisit(id uint) (*models.Visit, error)
+	RecallVisit(id uint) (*models.Visit, error)
+	UpdateVisitStatus(id uint, status string) (*models.Visit, error)
+
 	// Logs
-	GetAllLogs() ([]models.Log, error)
+	GetAllLogs(filter models.LogFilter) ([]models.Log, int64, error)
+
+	// Alerts
+	EvaluateInventoryAlerts() error
+	GetAlerts(status string) ([]models.Alert, error)
+	AcknowledgeAlert(id uint, pic string) (*models.Alert, error)
+	ResolveAlert(id uint, pic string) (*models.Alert, error)
+	UpdateMedicineAlertSettings(id string, req *models.UpdateAlertSettingsRequest) (*models.Medicine, error)
+
+	// Auth
+	Login(username, password string) (*models.TokenPair, *models.HospitalUser, error)
+	CreateUser(req *models.CreateHospitalUserRequest) (*models.HospitalUser, error)
+}
+
+// HospitalConfig berisi aturan bisnis yang bisa diatur per deployment
+type HospitalConfig struct {
+	// DispenseMinShelfDays: batch yang kedaluwarsa kurang dari N hari lagi tidak
+	// boleh diserahkan ke pasien. 0 berarti hanya batch yang sudah kedaluwarsa yang ditolak.
+	DispenseMinShelfDays int
+	// IDFormats mengganti format ID per jenis (lihat idformat.Defaults), mis.
+	// "RSP-{date:20060102}-{seq:4}"
+	IDFormats map[string]string
 }
 
 type hospitalService struct {
-	repo repository.HospitalRepository
+	repo   repository.HospitalRepository
+	tokens TokenService
+	events EventBus
+	config HospitalConfig
 }
 
-func NewHospitalService(repo repository.HospitalRepository) HospitalService {
-	return &hospitalService{repo: repo}
+func NewHospitalService(repo repository.HospitalRepository, tokens TokenService, events EventBus, config HospitalConfig) HospitalService {
+	s := &hospitalService{repo: repo, tokens: tokens, events: events, config: config}
+	tokens.RegisterSubject(SubjectHospitalUser, s.userClaims)
+	return s
+}
+
+// publish mengirim event setelah mutasi berhasil di-commit
+func (s *hospitalService) publish(topic, eventType string, data interface{}) {
+	if s.events != nil {
+		s.events.Publish(topic, eventType, data)
+	}
 }
 
 // ============ MEDICINE ============
 
-func (s *hospitalService) GetAllMedicines() ([]models.Medicine, error) {
-	return s.repo.GetAllMedicines()
+func (s *hospitalService) GetAllMedicines(filter models.MedicineFilter) ([]models.Medicine, int64, error) {
+	return s.repo.ListMedicines(filter)
 }
 
-func (s *hospitalService) CreateMedicine(medicine *models.Medicine) error {
-	// Auto-generate ID with prefix OBT- if not provided
-	if medicine.ID == "" {
-		// Try a few times to avoid collision
-		for i := 0; i < 5; i++ {
-			newID := fmt.Sprintf("OBT%04d", rand.Intn(9000)+1000)
-			// Check if ID exists; if not found, use it
-			_, err := s.repo.GetMedicineByID(newID)
+func (s *hospitalService) CreateMedicine(req *models.CreateMedicineRequest) (*models.Medicine, error) {
+	expiry, err := models.ParseDate(req.Expiry)
+	if err != nil {
+		return nil, Validation("invalid_expiry", "%s", err.Error())
+	}
+	medicine := &models.Medicine{
+		ID:                req.ID,
+		Name:              strings.TrimSpace(req.Name),
+		Type:              req.Type,
+		Stock:             req.Stock,
+		Price:             req.Price,
+		Expiry:            expiry,
+		Location:          req.Location,
+		ReorderThreshold:  req.ReorderThreshold,
+		ExpiryWarningDays: req.ExpiryWarningDays,
+		Ingredients:       req.Ingredients,
+	}
+
+	// Stok awal dicatat sebagai batch pertama
+	initialStock := req.Stock
+	err = s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		// Auto-generate ID jika tidak diisi
+		if medicine.ID == "" {
+			id, err := s.nextID(tx, IDKindMedicine)
 			if err != nil {
-				medicine.ID = newID
-				break
+				return err
 			}
+			medicine.ID = id
 		}
-		// Fallback in case all attempts found collision
-		if medicine.ID == "" {
-			medicine.ID = fmt.Sprintf("OBT%d", time.Now().Unix())
+		if err := tx.CreateMedicine(medicine); err != nil {
+			return err
+		}
+		if initialStock <= 0 {
+			return nil
+		}
+		batch := &models.MedicineBatch{
+			MedicineID:   medicine.ID,
+			LotNumber:    "INITIAL",
+			Expiry:       medicine.Expiry,
+			Qty:          initialStock,
+			InitialQty:   initialStock,
+			ReceivedDate: models.Today(),
+		}
+		if err := tx.CreateBatch(batch); err != nil {
+			return err
 		}
+		medicine.Batches = []models.MedicineBatch{*batch}
+		return tx.SyncMedicineStock(medicine.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.CreateMedicine(medicine)
+	s.publish(TopicMedicines, "medicine.created", medicine)
+	return medicine, nil
 }
 
-func (s *hospitalService) RestockMedicine(id string, amount int, pic string) error {
-	// Get medicine info for log
-	medicine, err := s.repo.GetMedicineByID(id)
+// RestockMedicine mencatat kiriman baru sebagai batch tersendiri
+func (s *hospitalService) RestockMedicine(id string, req *models.RestockRequest, pic string) error {
+	if req.Amount <= 0 {
+		return Validation("invalid_amount", "restock amount must be positive")
+	}
+
+	var batch *models.MedicineBatch
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		// Get medicine info for log
+		medicine, err := tx.GetMedicineByIDForUpdate(id)
+		if err != nil {
+			return notFoundOr(err, "medicine_not_found", "medicine %s not found", id)
+		}
+
+		receivedDate := req.ReceivedDate
+		if receivedDate.IsZero() {
+			receivedDate = models.Today()
+		}
+
+		// Create batch
+		batch = &models.MedicineBatch{
+			MedicineID:   id,
+			LotNumber:    req.LotNumber,
+			Expiry:       req.Expiry,
+			Qty:          req.Amount,
+			InitialQty:   req.Amount,
+			Supplier:     req.Supplier,
+			ReceivedDate: receivedDate,
+		}
+		if err := tx.CreateBatch(batch); err != nil {
+			return err
+		}
+
+		// Update stock
+		if err := tx.SyncMedicineStock(id); err != nil {
+			return err
+		}
+
+		// Create log
+		ref := "Restock"
+		if req.LotNumber != "" {
+			ref = "Restock " + req.LotNumber
+		}
+		log := &models.Log{
+			Type:         "IN",
+			MedicineName: medicine.Name,
+			Qty:          req.Amount,
+			Ref:          ref,
+			Pic:          pic,
+		}
+		return tx.CreateLog(log)
+	})
 	if err != nil {
 		return err
 	}
 
-	// Update stock
-	err = s.repo.RestockMedicine(id, amount)
+	s.publish(TopicMedicines, "medicine.restocked", batch)
+	return nil
+}
+
+func (s *hospitalService) UpdateMedicineIngredients(id string, req *models.UpdateIngredientsRequest) (*models.Medicine, error) {
+	for i, ingredient := range req.Ingredients {
+		if strings.TrimSpace(ingredient.Ingredient) == "" {
+			return nil, Validation("ingredient_required", "ingredient name cannot be empty")
+		}
+		req.Ingredients[i].Ingredient = strings.TrimSpace(ingredient.Ingredient)
+		req.Ingredients[i].DrugClass = strings.TrimSpace(ingredient.DrugClass)
+	}
+
+	var medicine *models.Medicine
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		// Kunci baris obat agar dua pembaruan bersamaan tidak saling menumpuk
+		var err error
+		medicine, err = tx.GetMedicineByIDForUpdate(id)
+		if err != nil {
+			return notFoundOr(err, "medicine_not_found", "medicine %s not found", id)
+		}
+		return tx.ReplaceMedicineIngredients(id, req.Ingredients)
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	medicine.Ingredients = req.Ingredients
+	s.publish(TopicMedicines, "medicine.updated", medicine)
+	return medicine, nil
+}
+
+// UpdateMedicinePrice mengganti harga obat dan mencatatnya di riwayat harga.
+// Resep yang sudah ditulis tetap memakai harga snapshot-nya.
+func (s *hospitalService) UpdateMedicinePrice(id string, req *models.UpdatePriceRequest, pic string) (*models.Medicine, error) {
+	if req.Price < 0 {
+		return nil, Validation("invalid_price", "price cannot be negative")
+	}
+
+	var medicine *models.Medicine
+	changed := false
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		var err error
+		medicine, err = tx.GetMedicineByIDForUpdate(id)
+		if err != nil {
+			return notFoundOr(err, "medicine_not_found", "medicine %s not found", id)
+		}
+		if medicine.Price == req.Price {
+			return nil
+		}
+
+		medicine.Price = req.Price
+		if err := tx.UpdateMedicine(medicine); err != nil {
+			return err
+		}
+		changed = true
+		return tx.CreateMedicinePrice(&models.MedicinePrice{
+			MedicineID:    id,
+			Price:         req.Price,
+			EffectiveFrom: time.Now(),
+			ChangedBy:     pic,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Create log
-	log := &models.Log{
-		Type:         "IN",
-		MedicineName: medicine.Name,
-		Qty:          amount,
-		Ref:          "Restock",
-		Pic:          pic,
+	if changed {
+		s.publish(TopicMedicines, "medicine.updated", medicine)
 	}
-	return s.repo.CreateLog(log)
+	return medicine, nil
+}
+
+func (s *hospitalService) GetMedicinePrices(id string) ([]models.MedicinePrice, error) {
+	if _, err := s.repo.GetMedicineByID(id); err != nil {
+		return nil, notFoundOr(err, "medicine_not_found", "medicine %s not found", id)
+	}
+	return s.repo.GetMedicinePrices(id)
 }
 
 // ============ PRESCRIPTION ============
 
-func (s *hospitalService) GetAllPrescriptions() ([]models.Prescription, error) {
-	return s.repo.GetAllPrescriptions()
+func (s *hospitalService) GetAllPrescriptions(filter models.PrescriptionFilter) ([]models.Prescription, int64, error) {
+	return s.repo.GetAllPrescriptions(filter)
 }
 
-func (s *hospitalService) CreatePrescription(req *models.CreatePrescriptionRequest) (*models.Prescription, error) {
-	// Generate prescription ID
-	prescriptionID := fmt.Sprintf("RSP-%04d", rand.Intn(9000)+1000)
+// buildPrescriptionItems mengisi nama dan harga setiap item dari katalog obat,
+// bukan dari request. Item menyimpan snapshot harga beserta baris riwayat harga
+// yang berlaku, sehingga perubahan harga berikutnya tidak mengubah resep ini.
+func (s *hospitalService) buildPrescriptionItems(reqItems []models.CreatePrescriptionItemRequest) ([]models.PrescriptionItem, int, error) {
+	ids := make([]string, 0, len(reqItems))
+	for _, item := range reqItems {
+		ids = append(ids, item.MedicineID)
+	}
+	medicines, err := s.repo.GetMedicinesByIDs(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	prices, err := s.repo.GetCurrentPrices(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	medicineByID := make(map[string]models.Medicine, len(medicines))
+	for _, m := range medicines {
+		medicineByID[m.ID] = m
+	}
+	currentPrice := make(map[string]models.MedicinePrice, len(prices))
+	for _, p := range prices {
+		currentPrice[p.MedicineID] = p
+	}
 
-	// Calculate total price
 	totalPrice := 0
 	items := []models.PrescriptionItem{}
-	for _, item := range req.Items {
-		totalPrice += item.Price * item.Qty
+	for _, item := range reqItems {
+		medicine, ok := medicineByID[item.MedicineID]
+		if !ok {
+			return nil, 0, unprocessable(NotFound("medicine_not_found", "medicine %s not found", item.MedicineID))
+		}
+		// Setiap obat mendapat baris harga saat dibuat; jika tidak ada, data
+		// rusak dan resep tidak boleh ditagih dengan harga tebakan
+		price, ok := currentPrice[medicine.ID]
+		if !ok {
+			return nil, 0, fmt.Errorf("medicine %s has no price history", medicine.ID)
+		}
+
+		totalPrice += price.Price * item.Qty
 		items = append(items, models.PrescriptionItem{
-			PrescriptionID: prescriptionID,
-			MedicineID:     item.MedicineID,
-			Name:           item.Name,
-			Qty:            item.Qty,
-			Price:          item.Price,
-			Signa:          item.Signa,
+			MedicineID:            medicine.ID,
+			Name:                  medicine.Name,
+			Qty:                   item.Qty,
+			Price:                 price.Price,
+			PriceID:               &price.ID,
+			Signa:                 item.Signa,
+			AllergyOverrideReason: strings.TrimSpace(item.AllergyOverrideReason),
 		})
 	}
+	return items, totalPrice, nil
+}
+
+func (s *hospitalService) CreatePrescription(req *models.CreatePrescriptionRequest) (*models.Prescription, error) {
+	// Resep selalu terhubung ke pasien terdaftar; nama/DOB disalin dari data pasien
+	patient, err := s.resolvePatient(req)
+	if err != nil {
+		return nil, err
+	}
+	allergies := req.Allergies
+	if allergies == "" {
+		allergies = patient.Allergies
+	}
+
+	// Nama, harga dan total dihitung dari katalog obat
+	items, totalPrice, err := s.buildPrescriptionItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
 
 	prescription := &models.Prescription{
-		ID:          prescriptionID,
-		PatientName: req.PatientName,
-		PatientDob:  req.PatientDob,
-		Allergies:   req.Allergies,
+		PatientID:   &patient.ID,
+		PatientName: patient.Name,
+		PatientDob:  patient.Dob,
+		Allergies:   allergies,
 		DoctorName:  req.DoctorName,
 		Date:        time.Now().Format("2006-01-02"),
-		Status:      "Pending",
+		Status:      models.PrescriptionStatusPending,
 		TotalPrice:  totalPrice,
 		Items:       items,
 	}
 
-	err := s.repo.CreatePrescription(prescription)
+	// Cek alergi, interaksi dan stok kedaluwarsa. Hanya konflik alergi tanpa
+	// override yang menolak resep, sisanya dikembalikan sebagai peringatan.
+	check, err := s.checkPrescription(req, patient, items)
 	if err != nil {
 		return nil, err
 	}
+	if !check.CanSubmit {
+		return nil, &AllergyConflictError{Conflicts: check.unresolvedAllergies()}
+	}
 
+	// Header, items dan riwayat awal harus tersimpan bersama
+	err = s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		// ID diambil di dalam transaksi agar nomor tidak terpakai jika insert gagal
+		id, err := s.nextID(tx, IDKindPrescription)
+		if err != nil {
+			return err
+		}
+		prescription.ID = id
+		for i := range prescription.Items {
+			prescription.Items[i].PrescriptionID = id
+		}
+
+		if err := tx.CreatePrescription(prescription); err != nil {
+			return err
+		}
+		history := &models.PrescriptionHistory{
+			PrescriptionID: id,
+			Status:         models.PrescriptionStatusPending,
+			Pic:            req.DoctorName,
+			Note:           "Resep dibuat",
+		}
+		if err := tx.CreatePrescriptionHistory(history); err != nil {
+			return err
+		}
+		prescription.HistoryLogs = []models.PrescriptionHistory{*history}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prescription.Warnings = check.Warnings
+	prescription.Interactions = check.Interactions
+	s.publish(TopicPrescriptions, "prescription.created", prescription)
 	return prescription, nil
 }
 
-func (s *hospitalService) ProcessPrescription(id string) error {
-	// Get prescription details
-	prescription, err := s.repo.GetPrescriptionByID(id)
+// CheckPrescription menjalankan semua pengecekan CreatePrescription tanpa menyimpan apa pun
+func (s *hospitalService) CheckPrescription(req *models.CreatePrescriptionRequest) (*PrescriptionCheck, error) {
+	patient, err := s.resolvePatient(req)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	items, _, err := s.buildPrescriptionItems(req.Items)
+	if err != nil {
+		return nil, err
 	}
+	return s.checkPrescription(req, patient, items)
+}
 
-	// Reduce stock for each item
-	for _, item := range prescription.Items {
-		medicine, err := s.repo.GetMedicineByID(item.MedicineID)
+// resolvePatient mencari pasien dari patient_id, atau dari patient_name +
+// patient_dob jika patient_id kosong. Nama yang salah ketik akan ditolak.
+func (s *hospitalService) resolvePatient(req *models.CreatePrescriptionRequest) (*models.Patient, error) {
+	if req.PatientID != "" {
+		patient, err := s.repo.GetPatientByID(req.PatientID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, unprocessable(fmt.Errorf("%w: %s", ErrPatientNotFound, req.PatientID))
+		}
 		if err != nil {
-			return fmt.Errorf("medicine %s not found", item.MedicineID)
+			return nil, err
 		}
+		return patient, nil
+	}
+
+	patients, err := s.repo.FindPatientsByNameDob(req.PatientName, req.PatientDob)
+	if err != nil {
+		return nil, err
+	}
+	switch len(patients) {
+	case 0:
+		return nil, unprocessable(fmt.Errorf("%w: no registered patient named %q born %s", ErrPatientNotFound, req.PatientName, req.PatientDob))
+	case 1:
+		return &patients[0], nil
+	default:
+		return nil, unprocessable(fmt.Errorf("%w: %d patients named %q born %s, pass patient_id", ErrPatientNotFound, len(patients), req.PatientName, req.PatientDob))
+	}
+}
 
-		if medicine.Stock < item.Qty {
-			return fmt.Errorf("insufficient stock for %s", item.Name)
+// PrescriptionCheck adalah hasil pengecekan resep sebelum disimpan
+type PrescriptionCheck struct {
+	AllergyConflicts []AllergyConflict           `json:"allergy_conflicts"`
+	Interactions     []models.InteractionWarning `json:"interactions"`
+	Warnings         []string                    `json:"warnings"`
+	CanSubmit        bool                        `json:"can_submit"` // false jika ada konflik alergi tanpa override
+}
+
+func (c *PrescriptionCheck) unresolvedAllergies() []AllergyConflict {
+	unresolved := []AllergyConflict{}
+	for _, conflict := range c.AllergyConflicts {
+		if !conflict.Overridden {
+			unresolved = append(unresolved, conflict)
 		}
+	}
+	return unresolved
+}
+
+func (s *hospitalService) checkPrescription(req *models.CreatePrescriptionRequest, patient *models.Patient, items []models.PrescriptionItem) (*PrescriptionCheck, error) {
+	medicineIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		medicineIDs = append(medicineIDs, item.MedicineID)
+	}
+	ingredients, err := s.repo.GetIngredientsByMedicineIDs(medicineIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	allergies := allergyConflicts(req, patient, items, ingredients)
+
+	rules, err := s.repo.GetAllInteractionRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// Peringatkan dokter jika stok yang layak diserahkan tidak cukup
+	warnings, err := s.expiryWarnings(items)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &PrescriptionCheck{
+		AllergyConflicts: allergies,
+		Interactions:     findInteractions(rules, items, ingredients),
+		Warnings:         warnings,
+	}
+	check.CanSubmit = len(check.unresolvedAllergies()) == 0
+	return check, nil
+}
+
+// allergyConflicts menggabungkan alergi teks bebas di request dengan alergen
+// terstruktur milik pasien, lalu mencocokkannya dengan katalog zat aktif.
+// Item yang bentrok wajib punya AllergyOverrideReason.
+func allergyConflicts(req *models.CreatePrescriptionRequest, patient *models.Patient, items []models.PrescriptionItem, ingredients []models.MedicineIngredient) []AllergyConflict {
+	allergens := parseAllergies(req.Allergies)
+	for _, allergen := range patient.Allergens {
+		allergens = append(allergens, allergen.Allergen)
+	}
+
+	overridden := map[string]bool{}
+	for _, item := range items {
+		if item.AllergyOverrideReason != "" {
+			overridden[item.MedicineID] = true
+		}
+	}
+
+	conflicts := findAllergyConflicts(allergens, items, ingredients)
+	for i := range conflicts {
+		conflicts[i].Overridden = overridden[conflicts[i].MedicineID]
+	}
+	return conflicts
+}
+
+func (s *hospitalService) GetPatientPrescriptions(patientID string) ([]models.Prescription, error) {
+	if _, err := s.repo.GetPatientByID(patientID); err != nil {
+		return nil, notFoundOr(err, "patient_not_found", "patient not found: %s", patientID)
+	}
+	return s.repo.GetPrescriptionsByPatientID(patientID)
+}
+
+// PatientBackfillReport adalah hasil menghubungkan resep lama ke data pasien
+type PatientBackfillReport struct {
+	Linked    int      `json:"linked"`
+	Unmatched []string `json:"unmatched"` // ID resep tanpa pasien yang cocok
+	Ambiguous []string `json:"ambiguous"` // ID resep yang cocok dengan lebih dari satu pasien
+}
+
+// backfillPrescriptionPatients adalah nama backfill di tabel data_backfills
+const backfillPrescriptionPatients = "prescription_patients"
 
-		// Reduce stock
-		err = s.repo.RestockMedicine(item.MedicineID, -item.Qty)
+// BackfillPrescriptionPatients mengisi patient_id untuk resep lama dengan
+// mencocokkan nama + tanggal lahir. Resep yang tidak cocok/ambigu dibiarkan.
+// Hanya berjalan sekali per database; report nil jika sudah pernah dijalankan.
+func (s *hospitalService) BackfillPrescriptionPatients() (*PatientBackfillReport, error) {
+	var report *PatientBackfillReport
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		first, err := tx.MarkBackfillRun(backfillPrescriptionPatients)
+		if err != nil || !first {
+			return err
+		}
+
+		prescriptions, err := tx.GetUnlinkedPrescriptions()
 		if err != nil {
 			return err
 		}
+		report = &PatientBackfillReport{Unmatched: []string{}, Ambiguous: []string{}}
+		for _, prescription := range prescriptions {
+			patients, err := tx.FindPatientsByNameDob(prescription.PatientName, prescription.PatientDob)
+			if err != nil {
+				return err
+			}
+			switch len(patients) {
+			case 0:
+				report.Unmatched = append(report.Unmatched, prescription.ID)
+			case 1:
+				if err := tx.LinkPrescriptionPatient(prescription.ID, patients[0].ID); err != nil {
+					return err
+				}
+				report.Linked++
+			default:
+				report.Ambiguous = append(report.Ambiguous, prescription.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
 
-		// Create log
-		log := &models.Log{
-			Type:         "OUT",
-			MedicineName: item.Name,
-			Qty:          item.Qty,
-			Ref:          id,
-			Pic:          "Apoteker",
+// LoadInteractionRules mengganti tabel aturan interaksi dengan isi file .json/.csv
+func (s *hospitalService) LoadInteractionRules(path string) (int, error) {
+	rules, err := loadInteractionRulesFile(path)
+	if err != nil {
+		return 0, err
+	}
+	err = s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		return tx.ReplaceInteractionRules(rules)
+	})
+	return len(rules), err
+}
+
+// expiryWarnings mengecek apakah stok yang belum kedaluwarsa cukup untuk
+// item resep. Resep tetap boleh dibuat; ProcessPrescription yang akan menolak.
+func (s *hospitalService) expiryWarnings(items []models.PrescriptionItem) ([]string, error) {
+	// Jumlahkan qty per obat, satu obat bisa muncul di beberapa item
+	needed := map[string]int{}
+	names := map[string]string{}
+	order := []string{}
+	for _, item := range items {
+		if _, seen := needed[item.MedicineID]; !seen {
+			order = append(order, item.MedicineID)
 		}
-		s.repo.CreateLog(log)
+		needed[item.MedicineID] += item.Qty
+		names[item.MedicineID] = item.Name
 	}
 
-	// Update status to Process
-	return s.repo.UpdatePrescriptionStatus(id, "Process")
+	warnings := []string{}
+	for _, medicineID := range order {
+		batches, err := s.repo.GetAvailableBatches(medicineID)
+		if err != nil {
+			return nil, err
+		}
+		usable, rejected := splitUsableBatches(batches, s.dispenseCutoff())
+		if len(rejected) == 0 || totalQty(usable) >= needed[medicineID] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"Stok %s yang layak diserahkan hanya %d dari %d; lot %s kedaluwarsa %s",
+			names[medicineID], totalQty(usable), needed[medicineID], rejected[0].LotNumber, rejected[0].Expiry,
+		))
+	}
+	return warnings, nil
 }
 
-func (s *hospitalService) FinishPrescription(id string) error {
-	return s.repo.UpdatePrescriptionStatus(id, "Selesai")
+// ProcessPrescription mengurangi stok untuk semua item resep dalam satu
+// transaksi. Jika salah satu item gagal, tidak ada stok yang berkurang dan
+// status resep tetap seperti semula.
+func (s *hospitalService) ProcessPrescription(id string, pic string, note string) error {
+	var from string
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		// Lock resep agar tidak diproses dua kali secara bersamaan
+		prescription, err := tx.GetPrescriptionByIDForUpdate(id)
+		if err != nil {
+			return notFoundOr(err, "prescription_not_found", "prescription %s not found", id)
+		}
+
+		// Hanya resep yang sudah diverifikasi yang boleh mengurangi stok
+		if err := checkPrescriptionTransition(prescription.Status, models.PrescriptionStatusProcess); err != nil {
+			return err
+		}
+		from = prescription.Status
+
+		// Lock obat dengan urutan ID yang konsisten untuk menghindari deadlock
+		items := make([]models.PrescriptionItem, len(prescription.Items))
+		copy(items, prescription.Items)
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].MedicineID < items[j].MedicineID
+		})
+
+		// Reduce stock for each item
+		for _, item := range items {
+			medicine, err := tx.GetMedicineByIDForUpdate(item.MedicineID)
+			if err != nil {
+				return notFoundOr(err, "medicine_not_found", "medicine %s not found", item.MedicineID)
+			}
+
+			if medicine.Stock < item.Qty {
+				return &InsufficientStockError{
+					MedicineID:   medicine.ID,
+					MedicineName: medicine.Name,
+					Available:    medicine.Stock,
+					Requested:    item.Qty,
+				}
+			}
+
+			// Ambil dari batch dengan expiry terdekat dulu (FEFO)
+			if err := s.dispenseFEFO(tx, medicine, item); err != nil {
+				return err
+			}
+
+			// Create log
+			log := &models.Log{
+				Type:         "OUT",
+				MedicineName: item.Name,
+				Qty:          item.Qty,
+				Ref:          id,
+				Pic:          pic,
+			}
+			if err := tx.CreateLog(log); err != nil {
+				return err
+			}
+		}
+
+		return setPrescriptionStatus(tx, prescription, models.PrescriptionStatusProcess, pic, note)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishPrescriptionStatus(id, from, models.PrescriptionStatusProcess, pic)
+	// Stok obat ikut berubah
+	s.publish(TopicMedicines, "medicine.dispensed", map[string]string{"prescription_id": id})
+	return nil
 }
 
-// ============ PATIENT ============
+// dispenseFEFO mengurangi qty batch untuk satu item resep, mulai dari batch
+// yang paling cepat kedaluwarsa, dan mencatat batch yang dipakai. Batch yang
+// sudah (atau hampir) kedaluwarsa dilewati.
+func (s *hospitalService) dispenseFEFO(tx repository.HospitalRepository, medicine *models.Medicine, item models.PrescriptionItem) error {
+	batches, err := tx.GetAvailableBatchesForUpdate(item.MedicineID)
+	if err != nil {
+		return err
+	}
 
-func (s *hospitalService) GetAllPatients() ([]models.Patient, error) {
-	return s.repo.GetAllPatients()
-}
-
-func (s *hospitalService) AddPatient(req *models.AddPatientRequest) (*models.Patient, error) {
-	// Generate patient ID with collision checking
-	var patientID string
-	patients, _ := s.repo.GetAllPatients()
-	
-	// Try sequential numbering first
-	for i := 1; i <= len(patients)+10; i++ {
-		testID := fmt.Sprintf("P-%03d", i)
-		// Check if ID exists
-		found := false
-		for _, p := range patients {
-			if p.ID == testID {
-				found = true
-				break
+	usable, rejected := splitUsableBatches(batches, s.dispenseCutoff())
+	if qty := totalQty(usable); qty < item.Qty {
+		if len(rejected) > 0 {
+			return &ExpiredStockError{
+				MedicineID:   medicine.ID,
+				MedicineName: medicine.Name,
+				Expiry:       rejected[0].Expiry,
+				Usable:       qty,
+				Requested:    item.Qty,
 			}
 		}
-		if !found {
-			patientID = testID
+		return &InsufficientStockError{
+			MedicineID:   medicine.ID,
+			MedicineName: medicine.Name,
+			Available:    qty,
+			Requested:    item.Qty,
+		}
+	}
+
+	remaining := item.Qty
+	for _, batch := range usable {
+		if remaining == 0 {
 			break
 		}
+		take := batch.Qty
+		if take > remaining {
+			take = remaining
+		}
+
+		if err := tx.ConsumeBatch(batch.ID, take); err != nil {
+			return err
+		}
+		err := tx.CreatePrescriptionItemBatch(&models.PrescriptionItemBatch{
+			PrescriptionItemID: item.ID,
+			BatchID:            batch.ID,
+			LotNumber:          batch.LotNumber,
+			Expiry:             batch.Expiry,
+			Qty:                take,
+		})
+		if err != nil {
+			return err
+		}
+		remaining -= take
 	}
-	
-	// Fallback if all sequential attempts failed
-	if patientID == "" {
-		patientID = fmt.Sprintf("P-%d", time.Now().Unix()%10000)
+
+	return tx.SyncMedicineStock(item.MedicineID)
+}
+
+// dispenseCutoff adalah tanggal expiry paling awal yang masih boleh diserahkan
+func (s *hospitalService) dispenseCutoff() models.Date {
+	return models.Today().AddDays(s.config.DispenseMinShelfDays)
+}
+
+// splitUsableBatches memisahkan batch yang boleh diserahkan dari yang
+// kedaluwarsa sebelum cutoff. Batch tanpa expiry dianggap boleh.
+func splitUsableBatches(batches []models.MedicineBatch, cutoff models.Date) (usable, rejected []models.MedicineBatch) {
+	for _, batch := range batches {
+		if !batch.Expiry.IsZero() && batch.Expiry.Before(cutoff.Time) {
+			rejected = append(rejected, batch)
+		} else {
+			usable = append(usable, batch)
+		}
 	}
+	return usable, rejected
+}
 
-	patient := &models.Patient{
-		ID:        patientID,
-		Name:      req.Name,
-		Dob:       req.Dob,
-		Status:    "Waiting",
-		Allergies: req.Allergies,
+func totalQty(batches []models.MedicineBatch) int {
+	total := 0
+	for _, batch := range batches {
+		total += batch.Qty
 	}
+	return total
+}
 
-	if patient.Allergies == "" {
-		patient.Allergies = "-"
+// TransitionPrescription memindahkan resep ke status berikutnya sesuai state machine.
+// Perpindahan ke Process didelegasikan ke ProcessPrescription karena mengurangi stok.
+func (s *hospitalService) TransitionPrescription(id string, status string, pic string, note string) error {
+	if status == models.PrescriptionStatusProcess {
+		return s.ProcessPrescription(id, pic, note)
 	}
 
-	err := s.repo.CreatePatient(patient)
+	var from string
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		prescription, err := tx.GetPrescriptionByIDForUpdate(id)
+		if err != nil {
+			return notFoundOr(err, "prescription_not_found", "prescription %s not found", id)
+		}
+
+		if err := checkPrescriptionTransition(prescription.Status, status); err != nil {
+			return err
+		}
+
+		from = prescription.Status
+		return setPrescriptionStatus(tx, prescription, status, pic, note)
+	})
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	s.publishPrescriptionStatus(id, from, status, pic)
+	return nil
+}
+
+// PrescriptionStatusEvent adalah payload event perubahan status resep
+type PrescriptionStatusEvent struct {
+	ID         string `json:"id"`
+	FromStatus string `json:"from_status"`
+	Status     string `json:"status"`
+	Pic        string `json:"pic"`
+}
+
+func (s *hospitalService) publishPrescriptionStatus(id, from, status, pic string) {
+	s.publish(TopicPrescriptions, "prescription.status_changed", PrescriptionStatusEvent{
+		ID:         id,
+		FromStatus: from,
+		Status:     status,
+		Pic:        pic,
+	})
+}
+
+// setPrescriptionStatus menyimpan status baru beserta baris riwayatnya
+func setPrescriptionStatus(tx repository.HospitalRepository, prescription *models.Prescription, status string, pic string, note string) error {
+	if err := tx.UpdatePrescriptionStatus(prescription.ID, status); err != nil {
+		return err
+	}
+
+	return tx.CreatePrescriptionHistory(&models.PrescriptionHistory{
+		PrescriptionID: prescription.ID,
+		FromStatus:     prescription.Status,
+		Status:         status,
+		Pic:            pic,
+		Note:           note,
+	})
+}
+
+// ============ PATIENT ============
+
+func (s *hospitalService) GetAllPatients(filter models.PatientFilter) ([]models.Patient, int64, error) {
+	return s.repo.GetAllPatients(filter)
+}
+
+// AddPatient mendaftarkan pasien ke master index (atau memakai data lama jika
+// NIK sudah terdaftar) lalu memasukkannya ke antrean kecuali RegisterOnly.
+func (s *hospitalService) AddPatient(req *models.AddPatientRequest) (*models.Patient, bool, error) {
+	var patient *models.Patient
+	if req.NIK != "" {
+		existing, err := s.repo.FindPatientByNIK(req.NIK)
+		switch {
+		case err == nil:
+			// NIK sama tapi identitas beda: jangan diam-diam memakai rekam medis orang lain
+			if mismatch := patientIdentityMismatch(existing, req); len(mismatch) > 0 {
+				return nil, false, &PatientNIKConflictError{NIK: req.NIK, PatientID: existing.ID, Fields: mismatch}
+			}
+			patient = existing
+		case !errors.Is(err, repository.ErrNotFound):
+			return nil, false, err
+		}
+	}
+
+	isNew := patient == nil
+	if isNew {
+		// ID dan nomor rekam medis diisi di dalam transaksi di bawah
+		patient = &models.Patient{
+			Name:      req.Name,
+			Dob:       req.Dob,
+			Gender:    req.Gender,
+			BloodType: req.BloodType,
+			Phone:     req.Phone,
+			Address:   req.Address,
+			Allergies: req.Allergies,
+		}
+		if req.NIK != "" {
+			nik := req.NIK
+			patient.NIK = &nik
+		}
+
+		if patient.Allergies == "" {
+			patient.Allergies = "-"
+		}
+
+		// Simpan juga versi terstruktur untuk cek alergi saat meresepkan
+		for _, allergen := range parseAllergies(patient.Allergies) {
+			patient.Allergens = append(patient.Allergens, models.PatientAllergen{Allergen: allergen})
+		}
+	}
+
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		if isNew {
+			id, err := s.nextID(tx, IDKindPatient)
+			if err != nil {
+				return err
+			}
+			patient.ID = id
+			patient.MedicalRecordNumber = "RM-" + strings.TrimPrefix(id, "P-")
+			if err := tx.CreatePatient(patient); err != nil {
+				return err
+			}
+		}
+		if req.RegisterOnly {
+			return nil
+		}
+		visit, err := openVisit(tx, patient, req.Polyclinic, req.Priority)
+		patient.CurrentVisit = visit
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if isNew {
+		s.publish(TopicPatients, "patient.added", patient)
+	}
+	if patient.CurrentVisit != nil {
+		s.publish(TopicQueue, "visit.created", patient.CurrentVisit)
 	}
+	return patient, isNew, nil
+}
 
+// patientIdentityMismatch membandingkan identitas request dengan pasien yang
+// sudah terdaftar dengan NIK yang sama
+func patientIdentityMismatch(existing *models.Patient, req *models.AddPatientRequest) []string {
+	var fields []string
+	if !strings.EqualFold(strings.TrimSpace(existing.Name), strings.TrimSpace(req.Name)) {
+		fields = append(fields, "name")
+	}
+	if existing.Dob != req.Dob {
+		fields = append(fields, "dob")
+	}
+	return fields
+}
+
+func (s *hospitalService) UpdatePatientAllergens(id string, req *models.UpdateAllergensRequest) (*models.Patient, error) {
+	names := []string{}
+	for i, allergen := range req.Allergens {
+		if strings.TrimSpace(allergen.Allergen) == "" {
+			return nil, Validation("allergen_required", "allergen cannot be empty")
+		}
+		req.Allergens[i].Allergen = strings.TrimSpace(allergen.Allergen)
+		names = append(names, req.Allergens[i].Allergen)
+	}
+
+	// Teks tampilan ikut diperbarui agar konsisten
+	allergies := "-"
+	if len(names) > 0 {
+		allergies = strings.Join(names, ", ")
+	}
+
+	var patient *models.Patient
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		var err error
+		patient, err = tx.GetPatientByIDForUpdate(id)
+		if err != nil {
+			return notFoundOr(err, "patient_not_found", "patient %s not found", id)
+		}
+		if err := tx.ReplacePatientAllergens(id, req.Allergens); err != nil {
+			return err
+		}
+		return tx.UpdatePatientAllergiesText(id, allergies)
+	})
+	if err != nil {
+		return nil, err
+	}
+	patient.Allergies = allergies
+	patient.Allergens = req.Allergens
+	s.publish(TopicPatients, "patient.updated", patient)
 	return patient, nil
 }
 
+// RemovePatient mengeluarkan pasien dari antrean dengan menutup kunjungan
+// aktifnya. Data pasien di master index tidak dihapus.
 func (s *hospitalService) RemovePatient(id string) error {
-	return s.repo.DeletePatient(id)
+	visit, err := s.repo.GetActiveVisitByPatientID(id)
+	if err != nil {
+		return notFoundOr(err, "not_in_queue", "patient is not in the queue: %s", id)
+	}
+
+	// Yang sudah diperiksa dianggap selesai, yang masih menunggu dianggap batal
+	status := models.VisitStatusCancelled
+	if visit.Status == models.VisitStatusExamining {
+		status = models.VisitStatusDone
+	}
+	_, err = s.UpdateVisitStatus(visit.ID, status)
+	return err
 }
 
 // ============ LOGS ============
 
-func (s *hospitalService) GetAllLogs() ([]models.Log, error) {
-	return s.repo.GetAllLogs()
+func (s *hospitalService) GetAllLogs(filter models.LogFilter) ([]models.Log, int64, error) {
+	return s.repo.GetAllLogs(filter)
+}
+
+// ============ ALERTS ============
+
+// alertKey mengidentifikasi satu kondisi alert (jenis + obat + batch)
+func alertKey(alert *models.Alert) string {
+	key := alert.Type + "|" + alert.MedicineID
+	if alert.BatchID != nil {
+		key += fmt.Sprintf("|%d", *alert.BatchID)
+	}
+	return key
+}
+
+// inventoryAlerts mengembalikan kondisi alert yang sedang aktif untuk satu obat.
+// med.Batches harus berisi batch yang masih ada stoknya.
+func inventoryAlerts(med *models.Medicine, today models.Date) []*models.Alert {
+	var alerts []*models.Alert
+	if med.Stock <= med.ReorderThreshold {
+		alerts = append(alerts, &models.Alert{
+			Type:         models.AlertTypeLowStock,
+			MedicineID:   med.ID,
+			MedicineName: med.Name,
+			Message:      fmt.Sprintf("Stok %s tinggal %d (batas reorder %d)", med.Name, med.Stock, med.ReorderThreshold),
+		})
+	}
+
+	for _, batch := range med.Batches {
+		if batch.Expiry.IsZero() || batch.Qty <= 0 {
+			continue
+		}
+		batchID := batch.ID
+		alert := &models.Alert{
+			MedicineID:   med.ID,
+			MedicineName: med.Name,
+			BatchID:      &batchID,
+		}
+		switch {
+		case batch.Expiry.Before(today.Time):
+			alert.Type = models.AlertTypeExpired
+			alert.Message = fmt.Sprintf("%s lot %s (%d unit) kedaluwarsa sejak %s", med.Name, batch.LotNumber, batch.Qty, batch.Expiry)
+		case batch.Expiry.Before(today.AddDays(med.ExpiryWarningDays + 1).Time):
+			alert.Type = models.AlertTypeExpiring
+			alert.Message = fmt.Sprintf("%s lot %s (%d unit) kedaluwarsa pada %s", med.Name, batch.LotNumber, batch.Qty, batch.Expiry)
+		default:
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// EvaluateInventoryAlerts membandingkan kondisi inventori saat ini dengan alert
+// yang belum resolved: kondisi baru dibuatkan alert, kondisi yang sudah hilang
+// (misal sudah restock) di-resolve otomatis oleh "system".
+func (s *hospitalService) EvaluateInventoryAlerts() error {
+	medicines, err := s.repo.GetAllMedicines()
+	if err != nil {
+		return err
+	}
+
+	today := models.Today()
+	desired := map[string]*models.Alert{}
+	for i := range medicines {
+		for _, alert := range inventoryAlerts(&medicines[i], today) {
+			desired[alertKey(alert)] = alert
+		}
+	}
+
+	existing, err := s.repo.GetUnresolvedAlerts()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range existing {
+		alert := &existing[i]
+		key := alertKey(alert)
+		if _, stillActive := desired[key]; stillActive {
+			delete(desired, key)
+			continue
+		}
+		from := alert.Status
+		alert.Status = models.AlertStatusResolved
+		alert.ResolvedBy = "system"
+		alert.ResolvedAt = &now
+		resolved, err := s.repo.TransitionAlert(alert, from)
+		if err != nil {
+			return err
+		}
+		if resolved {
+			s.publish(TopicAlerts, "alert.resolved", alert)
+		}
+	}
+
+	for _, alert := range desired {
+		alert.Status = models.AlertStatusOpen
+		err := s.repo.CreateAlert(alert)
+		if errors.Is(err, repository.ErrDuplicate) {
+			// Replica lain sudah membuat alert untuk kondisi yang sama
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.publish(TopicAlerts, "alert.created", alert)
+	}
+
+	return nil
+}
+
+func (s *hospitalService) GetAlerts(status string) ([]models.Alert, error) {
+	return s.repo.GetAlerts(status)
+}
+
+func (s *hospitalService) AcknowledgeAlert(id uint, pic string) (*models.Alert, error) {
+	alert, err := s.repo.GetAlertByID(id)
+	if err != nil {
+		return nil, notFoundOr(err, "alert_not_found", "alert %d not found", id)
+	}
+	if alert.Status != models.AlertStatusOpen {
+		return nil, Conflict("alert_already_handled", "alert is already %s", alert.Status)
+	}
+
+	now := time.Now()
+	alert.Status = models.AlertStatusAcknowledged
+	alert.AcknowledgedBy = pic
+	alert.AcknowledgedAt = &now
+	ok, err := s.repo.TransitionAlert(alert, models.AlertStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, Conflict("alert_already_handled", "alert was handled by another request")
+	}
+	s.publish(TopicAlerts, "alert.acknowledged", alert)
+	return alert, nil
+}
+
+// alertConditionActive mengecek apakah kondisi yang memicu alert masih ada
+func (s *hospitalService) alertConditionActive(alert *models.Alert) (bool, error) {
+	med, err := s.repo.GetMedicineByID(alert.MedicineID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if med.Batches, err = s.repo.GetAvailableBatches(med.ID); err != nil {
+		return false, err
+	}
+
+	key := alertKey(alert)
+	for _, active := range inventoryAlerts(med, models.Today()) {
+		if alertKey(active) == key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ResolveAlert menutup alert secara manual. Alert yang kondisinya masih ada
+// (stok masih di bawah batas, batch masih kedaluwarsa) ditolak, karena evaluasi
+// berikutnya hanya akan membuatnya lagi; alert seperti itu cukup di-acknowledge.
+func (s *hospitalService) ResolveAlert(id uint, pic string) (*models.Alert, error) {
+	alert, err := s.repo.GetAlertByID(id)
+	if err != nil {
+		return nil, notFoundOr(err, "alert_not_found", "alert %d not found", id)
+	}
+	if alert.Status == models.AlertStatusResolved {
+		return nil, Conflict("alert_already_handled", "alert is already %s", alert.Status)
+	}
+	active, err := s.alertConditionActive(alert)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, Conflict("alert_condition_active", "alert condition is still active; acknowledge it instead")
+	}
+
+	now := time.Now()
+	alert.Status = models.AlertStatusResolved
+	alert.ResolvedBy = pic
+	alert.ResolvedAt = &now
+	ok, err := s.repo.TransitionAlert(alert, models.AlertStatusOpen, models.AlertStatusAcknowledged)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, Conflict("alert_already_handled", "alert was handled by another request")
+	}
+	s.publish(TopicAlerts, "alert.resolved", alert)
+	return alert, nil
+}
+
+func (s *hospitalService) UpdateMedicineAlertSettings(id string, req *models.UpdateAlertSettingsRequest) (*models.Medicine, error) {
+	if req.ReorderThreshold < 0 || req.ExpiryWarningDays < 0 {
+		return nil, Validation("invalid_alert_settings", "reorder_threshold and expiry_warning_days cannot be negative")
+	}
+
+	if err := s.repo.UpdateMedicineAlertSettings(id, req.ReorderThreshold, req.ExpiryWarningDays); err != nil {
+		return nil, notFoundOr(err, "medicine_not_found", "medicine %s not found", id)
+	}
+	medicine, err := s.repo.GetMedicineByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(TopicMedicines, "medicine.updated", medicine)
+	return medicine, nil
+}
+
+// ============ AUTH ============
+
+// validRoles berisi role yang boleh dimiliki akun rumah sakit
+var validRoles = map[string]bool{
+	models.RoleDoctor:     true,
+	models.RolePharmacist: true,
+	models.RoleLogistics:  true,
+	models.RoleFrontDesk:  true,
+	models.RoleAdmin:      true,
+}
+
+// Login memverifikasi akun staf dan membuat JWT yang membawa role-nya
+func (s *hospitalService) Login(username, password string) (*models.TokenPair, *models.HospitalUser, error) {
+	user, err := s.repo.GetUserByUsername(username)
+	if errors.Is(err, repository.ErrNotFound) {
+		bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Akun staf selalu dibuat dengan hash bcrypt; nilai lain tidak pernah cocok
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	pair, err := s.tokens.Issue(Subject(SubjectHospitalUser, user.ID), hospitalUserClaims(user))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pair, user, nil
+}
+
+// hospitalUserClaims adalah isi access token staf; role dibaca RequireRoles
+func hospitalUserClaims(user *models.HospitalUser) jwt.MapClaims {
+	return jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"name":     user.Name,
+		"role":     user.Role,
+	}
+}
+
+// userClaims dipakai TokenService saat refresh token staf
+func (s *hospitalService) userClaims(id uint) (jwt.MapClaims, error) {
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return hospitalUserClaims(user), nil
+}
+
+func (s *hospitalService) CreateUser(req *models.CreateHospitalUserRequest) (*models.HospitalUser, error) {
+	if req.Username == "" || req.Password == "" || req.Name == "" {
+		return nil, Validation("missing_fields", "username, name and password are required")
+	}
+	if !validRoles[req.Role] {
+		return nil, Validation("invalid_role", "invalid role %q", req.Role)
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.HospitalUser{
+		Username: req.Username,
+		Name:     req.Name,
+		Role:     req.Role,
+		Password: hash,
+	}
+	if err := s.repo.CreateUser(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
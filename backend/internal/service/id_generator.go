@@ -0,0 +1,35 @@
+package services
+
+import (
+	"backend/internal/idformat"
+	"backend/internal/repository"
+	"time"
+)
+
+// Jenis ID yang dibuat dari sequence database
+const (
+	IDKindPrescription = idformat.Prescription
+	IDKindMedicine     = idformat.Medicine
+	IDKindPatient      = idformat.Patient
+)
+
+// nextID mengambil nilai sequence berikutnya dan merangkai ID sesuai format.
+// Panggil di dalam transaksi yang sama dengan insert-nya agar nomor tidak bolong
+// saat insert gagal.
+func (s *hospitalService) nextID(tx repository.HospitalRepository, kind string) (string, error) {
+	format := s.config.IDFormats[kind]
+	if format == "" {
+		format = idformat.Defaults[kind]
+	}
+	if err := idformat.Validate(format); err != nil {
+		return "", err
+	}
+
+	// Bagian tanggal menjadi periode sequence, jadi counter reset per hari/bulan/...
+	period := idformat.Period(format, time.Now())
+	value, err := tx.NextSequenceValue(kind, period)
+	if err != nil {
+		return "", err
+	}
+	return idformat.Render(format, period, value), nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"backend/internal/models"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCreateMedicineConcurrentIDsAreUniqueAndSequential(t *testing.T) {
+	s, _ := newTestService(t)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	ids := make([]string, workers)
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			medicine, err := s.CreateMedicine(&models.CreateMedicineRequest{Name: fmt.Sprintf("Obat %d", i)})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i] = medicine.ID
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for i, id := range ids {
+		if errs[i] != nil {
+			t.Fatalf("CreateMedicine: %v", errs[i])
+		}
+		if seen[id] {
+			t.Errorf("duplicate id %s", id)
+		}
+		seen[id] = true
+	}
+	// Tidak ada nomor yang bolong atau terpakai dua kali
+	for n := 1; n <= workers; n++ {
+		if id := fmt.Sprintf("OBT-%05d", n); !seen[id] {
+			t.Errorf("missing id %s", id)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"backend/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var validSeverities = map[string]bool{
+	models.SeverityMinor:           true,
+	models.SeverityModerate:        true,
+	models.SeverityMajor:           true,
+	models.SeverityContraindicated: true,
+}
+
+// loadInteractionRulesFile membaca aturan interaksi dari file .json (array of
+// InteractionRule) atau .csv (header: subject_a,subject_b,severity,message)
+func loadInteractionRulesFile(path string) ([]models.InteractionRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []models.InteractionRule
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(file).Decode(&rules); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".csv":
+		rules, err = parseInteractionCSV(file)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported interaction rules file %s, use .json or .csv", path)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		rule.ID = 0
+		rule.SubjectA = strings.TrimSpace(rule.SubjectA)
+		rule.SubjectB = strings.TrimSpace(rule.SubjectB)
+		rule.Severity = strings.ToLower(strings.TrimSpace(rule.Severity))
+		if rule.SubjectA == "" || rule.SubjectB == "" {
+			return nil, fmt.Errorf("rule %d: subject_a and subject_b are required", i+1)
+		}
+		if !validSeverities[rule.Severity] {
+			return nil, fmt.Errorf("rule %d: invalid severity %q", i+1, rule.Severity)
+		}
+	}
+	return rules, nil
+}
+
+func parseInteractionCSV(r io.Reader) ([]models.InteractionRule, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	// Kolom dicari berdasarkan header agar urutannya bebas
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"subject_a", "subject_b", "severity", "message"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	rules := make([]models.InteractionRule, 0, len(records)-1)
+	for _, record := range records[1:] {
+		rules = append(rules, models.InteractionRule{
+			SubjectA: record[columns["subject_a"]],
+			SubjectB: record[columns["subject_b"]],
+			Severity: record[columns["severity"]],
+			Message:  record[columns["message"]],
+		})
+	}
+	return rules, nil
+}
+
+// findInteractions mengevaluasi semua aturan pada setiap pasangan item resep
+// yang berbeda obat. Satu aturan hanya dilaporkan sekali per pasangan.
+func findInteractions(rules []models.InteractionRule, items []models.PrescriptionItem, ingredients []models.MedicineIngredient) []models.InteractionWarning {
+	// Subjek (zat aktif + golongan, lowercase) untuk setiap obat
+	subjects := map[string]map[string]bool{}
+	for _, ingredient := range ingredients {
+		set := subjects[ingredient.MedicineID]
+		if set == nil {
+			set = map[string]bool{}
+			subjects[ingredient.MedicineID] = set
+		}
+		set[strings.ToLower(ingredient.Ingredient)] = true
+		if ingredient.DrugClass != "" {
+			set[strings.ToLower(ingredient.DrugClass)] = true
+		}
+	}
+
+	warnings := []models.InteractionWarning{}
+	seen := map[string]bool{}
+	for i := 0; i < len(items); i++ {
+		for j := i + 1; j < len(items); j++ {
+			a, b := items[i], items[j]
+			if a.MedicineID == b.MedicineID {
+				continue
+			}
+			for _, rule := range rules {
+				subjectA := strings.ToLower(rule.SubjectA)
+				subjectB := strings.ToLower(rule.SubjectB)
+				matches := (subjects[a.MedicineID][subjectA] && subjects[b.MedicineID][subjectB]) ||
+					(subjects[a.MedicineID][subjectB] && subjects[b.MedicineID][subjectA])
+				if !matches {
+					continue
+				}
+
+				key := fmt.Sprintf("%d|%s|%s", rule.ID, a.MedicineID, b.MedicineID)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				warnings = append(warnings, models.InteractionWarning{
+					RuleID:    rule.ID,
+					MedicineA: a.Name,
+					MedicineB: b.Name,
+					Severity:  rule.Severity,
+					Message:   rule.Message,
+				})
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHospitalLoginUnknownUser(t *testing.T) {
+	s, _ := newTestService(t)
+	if _, _, err := s.Login("nobody", "whatever123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login error = %v, want ErrInvalidCredentials", err)
+	}
+
+	// Hash pengganti harus hash bcrypt yang valid dengan cost yang sama
+	// seperti akun asli, kalau tidak waktunya tetap berbeda
+	if cost, err := bcrypt.Cost([]byte(dummyPasswordHash)); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("dummyPasswordHash cost = %d, %v, want %d", cost, err, bcrypt.DefaultCost)
+	}
+}
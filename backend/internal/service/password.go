@@ -0,0 +1,41 @@
+package services
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+// dummyPasswordHash adalah hash bcrypt (cost default) yang tidak cocok dengan
+// password apa pun yang dipakai. Dibandingkan saat username tidak ada agar waktu
+// respons login tidak membocorkan username mana yang terdaftar.
+const dummyPasswordHash = "$2a$10$BcU4E9f9.EMNqFYv4/F/bOUcDzeRpXSbAIp4TXVneInuqQfMLA6Pq"
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", Validation("password_too_short", "password must be at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func isBcryptHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// checkPassword membandingkan password dengan nilai tersimpan. Baris lama yang
+// masih plaintext tetap diterima, tapi needsRehash bernilai true agar pemanggil
+// segera menggantinya dengan hash.
+func checkPassword(stored, password string) (ok bool, needsRehash bool) {
+	if isBcryptHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil, false
+	}
+	ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	return ok, ok
+}
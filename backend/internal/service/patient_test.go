@@ -0,0 +1,38 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestAddPatientWithExistingNIK(t *testing.T) {
+	s, _ := newTestService(t)
+	req := models.AddPatientRequest{Name: "Budi Santoso", Dob: "1990-01-01", NIK: "3171000000000001", RegisterOnly: true}
+	first, created, err := s.AddPatient(&req)
+	if err != nil || !created {
+		t.Fatalf("AddPatient = %v, %v, want created", created, err)
+	}
+
+	// Kunjungan ulang dengan identitas yang sama memakai rekam medis yang lama
+	again := req
+	again.Name = "budi santoso "
+	patient, created, err := s.AddPatient(&again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || patient.ID != first.ID {
+		t.Errorf("AddPatient = %s created=%v, want existing %s", patient.ID, created, first.ID)
+	}
+
+	other := req
+	other.Name = "Siti Aminah"
+	_, _, err = s.AddPatient(&other)
+	var conflict *PatientNIKConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("error = %v, want PatientNIKConflictError", err)
+	}
+	if conflict.PatientID != first.ID || len(conflict.Fields) != 1 || conflict.Fields[0] != "name" {
+		t.Errorf("conflict = %+v", conflict)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"backend/internal/models"
+	"fmt"
+)
+
+// ErrInvalidTransition dikembalikan jika perpindahan status resep tidak diizinkan
+var ErrInvalidTransition = Conflict("invalid_transition", "invalid prescription status transition")
+
+// prescriptionTransitions adalah state machine resep: status asal -> status tujuan yang sah.
+// Status yang tidak punya entri (Selesai, Cancelled, Rejected) adalah status akhir.
+var prescriptionTransitions = map[string][]string{
+	models.PrescriptionStatusPending: {
+		models.PrescriptionStatusVerified,
+		models.PrescriptionStatusRejected,
+		models.PrescriptionStatusCancelled,
+	},
+	models.PrescriptionStatusVerified: {
+		models.PrescriptionStatusProcess,
+		models.PrescriptionStatusRejected,
+		models.PrescriptionStatusCancelled,
+	},
+	models.PrescriptionStatusProcess: {
+		models.PrescriptionStatusReady,
+	},
+	models.PrescriptionStatusReady: {
+		models.PrescriptionStatusSelesai,
+	},
+}
+
+// prescriptionActions memetakan ?action= dari handler ke status tujuan
+var prescriptionActions = map[string]string{
+	"verify":  models.PrescriptionStatusVerified,
+	"process": models.PrescriptionStatusProcess,
+	"ready":   models.PrescriptionStatusReady,
+	"finish":  models.PrescriptionStatusSelesai,
+	"cancel":  models.PrescriptionStatusCancelled,
+	"reject":  models.PrescriptionStatusRejected,
+}
+
+// PrescriptionStatusForAction mengembalikan status tujuan untuk sebuah action
+func PrescriptionStatusForAction(action string) (string, bool) {
+	status, ok := prescriptionActions[action]
+	return status, ok
+}
+
+func canTransitionPrescription(from, to string) bool {
+	for _, next := range prescriptionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func checkPrescriptionTransition(from, to string) error {
+	if !canTransitionPrescription(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestProcessPrescriptionIsAtomic(t *testing.T) {
+	s, repo := newTestService(t)
+	patient := createTestPatient(t, s)
+	plenty := createTestMedicine(t, s, "Amoxicillin", 10, "2099-01-01")
+	scarce := createTestMedicine(t, s, "Paracetamol", 2, "2099-01-01")
+
+	prescription := createVerifiedPrescription(t, s, patient.ID,
+		models.CreatePrescriptionItemRequest{MedicineID: plenty.ID, Qty: 5},
+		models.CreatePrescriptionItemRequest{MedicineID: scarce.ID, Qty: 5},
+	)
+
+	err := s.ProcessPrescription(prescription.ID, "tester", "")
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("ProcessPrescription error = %v, want InsufficientStockError", err)
+	}
+	if stockErr.MedicineID != scarce.ID || stockErr.Available != 2 || stockErr.Requested != 5 {
+		t.Errorf("InsufficientStockError = %+v", stockErr)
+	}
+
+	// Item pertama yang sudah dikurangi harus ikut batal
+	if got := medicineStock(t, repo, plenty.ID); got != 10 {
+		t.Errorf("stock of %s = %d after failed dispense, want 10", plenty.ID, got)
+	}
+	if got := medicineStock(t, repo, scarce.ID); got != 2 {
+		t.Errorf("stock of %s = %d after failed dispense, want 2", scarce.ID, got)
+	}
+	stored, err := repo.GetPrescriptionByID(prescription.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.PrescriptionStatusVerified {
+		t.Errorf("status = %s after failed dispense, want %s", stored.Status, models.PrescriptionStatusVerified)
+	}
+}
+
+func TestProcessPrescriptionConcurrentStockNeverNegative(t *testing.T) {
+	s, repo := newTestService(t)
+	patient := createTestPatient(t, s)
+	medicine := createTestMedicine(t, s, "Amoxicillin", 10, "2099-01-01")
+
+	const workers = 6
+	ids := make([]string, workers)
+	for i := range ids {
+		ids[i] = createVerifiedPrescription(t, s, patient.ID,
+			models.CreatePrescriptionItemRequest{MedicineID: medicine.ID, Qty: 3},
+		).ID
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.ProcessPrescription(id, "tester", "")
+		}()
+	}
+	wg.Wait()
+
+	dispensed := 0
+	for _, err := range errs {
+		var stockErr *InsufficientStockError
+		switch {
+		case err == nil:
+			dispensed++
+		case errors.As(err, &stockErr):
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if dispensed != 3 {
+		t.Errorf("dispensed %d prescriptions of qty 3 from stock 10, want 3", dispensed)
+	}
+	if got := medicineStock(t, repo, medicine.ID); got != 10-3*dispensed || got < 0 {
+		t.Errorf("stock = %d, want %d", got, 10-3*dispensed)
+	}
+}
+
+func TestProcessPrescriptionConsumesBatchesFEFO(t *testing.T) {
+	s, repo := newTestService(t)
+	patient := createTestPatient(t, s)
+	medicine := createTestMedicine(t, s, "Amoxicillin", 0, "")
+
+	restock := func(lot, expiry string, qty int) {
+		t.Helper()
+		date, err := models.ParseDate(expiry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RestockMedicine(medicine.ID, &models.RestockRequest{Amount: qty, LotNumber: lot, Expiry: date}, "tester"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restock("LATE", "2099-12-31", 5)
+	restock("EARLY", "2098-01-31", 3)
+	restock("MIDDLE", "2098-06-30", 4)
+
+	prescription := createVerifiedPrescription(t, s, patient.ID,
+		models.CreatePrescriptionItemRequest{MedicineID: medicine.ID, Qty: 5},
+	)
+	if err := s.ProcessPrescription(prescription.ID, "tester", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// EARLY habis, MIDDLE terpakai 2, LATE utuh
+	stored, err := repo.GetPrescriptionByID(prescription.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := map[string]int{}
+	for _, batch := range stored.Items[0].Batches {
+		used[batch.LotNumber] = batch.Qty
+	}
+	want := map[string]int{"EARLY": 3, "MIDDLE": 2}
+	if len(used) != len(want) || used["EARLY"] != 3 || used["MIDDLE"] != 2 {
+		t.Errorf("batches used = %v, want %v", used, want)
+	}
+
+	batches, err := repo.GetAvailableBatches(medicine.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining := map[string]int{}
+	for _, batch := range batches {
+		remaining[batch.LotNumber] = batch.Qty
+	}
+	if len(remaining) != 2 || remaining["MIDDLE"] != 2 || remaining["LATE"] != 5 {
+		t.Errorf("remaining batches = %v, want MIDDLE:2 LATE:5", remaining)
+	}
+	if got := medicineStock(t, repo, medicine.ID); got != 7 {
+		t.Errorf("stock = %d, want 7", got)
+	}
+}
+
+func TestTransitionPrescriptionRejectsIllegalTransition(t *testing.T) {
+	s, _ := newTestService(t)
+	patient := createTestPatient(t, s)
+	medicine := createTestMedicine(t, s, "Amoxicillin", 10, "2099-01-01")
+
+	prescription, err := s.CreatePrescription(&models.CreatePrescriptionRequest{
+		PatientID: patient.ID,
+		Items:     []models.CreatePrescriptionItemRequest{{MedicineID: medicine.ID, Qty: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{"skip verification", models.PrescriptionStatusReady},
+		{"finish pending", models.PrescriptionStatusSelesai},
+		{"dispense pending", models.PrescriptionStatusProcess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.TransitionPrescription(prescription.ID, tt.status, "tester", "")
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Fatalf("error = %v, want ErrInvalidTransition", err)
+			}
+			de, ok := AsDomainError(err)
+			if !ok || de.Kind() != KindConflict || de.Code() != "invalid_transition" {
+				t.Errorf("domain error = %v, want conflict invalid_transition", de)
+			}
+		})
+	}
+}
@@ -3,11 +3,10 @@ package services
 import (
 	"backend/internal/models"
 	"backend/internal/repository"
-	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type ProjectService interface {
@@ -16,15 +15,46 @@ type ProjectService interface {
 	CreateProject(req models.CreateProjectRequest) (models.Project, error)
 	RemoveProject(id string) error
 	// Tambahan untuk Auth
-	Login(username, password string) (string, error)
+	Login(username, password string) (*models.TokenPair, error)
+	CreateAdmin(req models.CreateAdminRequest) (models.Admin, error)
+	ChangePassword(adminID uint, oldPassword, newPassword string) error
+	ResetPassword(adminID uint, newPassword string) error
 }
 
+// ErrAccountLocked dikembalikan saat akun dikunci karena terlalu banyak login gagal
+var ErrAccountLocked = &Error{kind: KindTooManyRequests, code: "account_locked", msg: "account temporarily locked, try again later"}
+
+const (
+	maxFailedLogins = 5
+	lockoutDuration = 15 * time.Minute
+)
+
 type projectService struct {
-	repo repository.ProjectRepository
+	repo   repository.ProjectRepository
+	tokens TokenService
+}
+
+func NewProjectService(repo repository.ProjectRepository, tokens TokenService) ProjectService {
+	s := &projectService{repo, tokens}
+	tokens.RegisterSubject(SubjectAdmin, s.adminClaims)
+	return s
+}
+
+// adminTokenClaims adalah isi access token admin portfolio
+func adminTokenClaims(admin models.Admin) jwt.MapClaims {
+	return jwt.MapClaims{
+		"username": admin.Username,
+		"admin_id": admin.ID,
+	}
 }
 
-func NewProjectService(repo repository.ProjectRepository) ProjectService {
-	return &projectService{repo}
+// adminClaims dipakai TokenService saat refresh token admin
+func (s *projectService) adminClaims(id uint) (jwt.MapClaims, error) {
+	admin, err := s.repo.GetAdminByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return adminTokenClaims(admin), nil
 }
 
 func (s *projectService) GetAllProjects() ([]models.Project, error) {
@@ -37,7 +67,7 @@ func (s *projectService) GetProjectByID(id string) (models.Project, error) {
 
 func (s *projectService) CreateProject(req models.CreateProjectRequest) (models.Project, error) {
 	if req.Title == "" {
-		return models.Project{}, errors.New("title cannot be empty")
+		return models.Project{}, Validation("title_required", "title cannot be empty")
 	}
 	newProject := models.Project{
 		Title:       req.Title,
@@ -52,35 +82,105 @@ func (s *projectService) CreateProject(req models.CreateProjectRequest) (models.
 func (s *projectService) RemoveProject(id string) error {
 	_, err := s.repo.FindByID(id)
 	if err != nil {
-		return errors.New("project not found")
+		return notFoundOr(err, "project_not_found", "project %s not found", id)
 	}
 	return s.repo.Delete(id)
 }
 
 // Implementasi Baru: Login & Generate JWT
-func (s *projectService) Login(username, password string) (string, error) {
+func (s *projectService) Login(username, password string) (*models.TokenPair, error) {
 	// 1. Cari user di DB
 	admin, err := s.repo.GetAdminByUsername(username)
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	// 2. Tolak jika akun sedang dikunci
+	if admin.LockedUntil != nil && time.Now().Before(*admin.LockedUntil) {
+		return nil, ErrAccountLocked
 	}
 
-	// 2. Cek Password (Simple Compare)
-	if admin.Password != password {
-		return "", errors.New("invalid credentials")
+	// 3. Cek Password
+	ok, needsRehash := checkPassword(admin.Password, password)
+	if !ok {
+		// Counter dinaikkan di database; keputusan kunci memakai nilai hasil UPDATE
+		attempts, err := s.repo.IncrementFailedAttempts(admin.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= maxFailedLogins {
+			if err := s.repo.LockAdmin(admin.ID, time.Now().Add(lockoutDuration)); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidCredentials
 	}
 
-	// 3. Buat Token JWT
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["username"] = admin.Username
-	claims["admin_id"] = admin.ID
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix() // Token valid 72 jam
+	// Reset counter, dan migrasikan password plaintext lama ke hash
+	if err := s.repo.ResetFailedAttempts(admin.ID); err != nil {
+		return nil, err
+	}
+	if needsRehash {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateAdminPassword(admin.ID, string(hash)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Buat access token + refresh token
+	return s.tokens.Issue(Subject(SubjectAdmin, admin.ID), adminTokenClaims(admin))
+}
+
+func (s *projectService) CreateAdmin(req models.CreateAdminRequest) (models.Admin, error) {
+	if req.Username == "" {
+		return models.Admin{}, Validation("username_required", "username cannot be empty")
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return models.Admin{}, err
+	}
+	admin := models.Admin{
+		Username: req.Username,
+		Password: hash,
+	}
+	err = s.repo.CreateAdmin(&admin)
+	return admin, err
+}
 
-	t, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+func (s *projectService) ChangePassword(adminID uint, oldPassword, newPassword string) error {
+	admin, err := s.repo.GetAdminByID(adminID)
+	if err != nil {
+		return notFoundOr(err, "admin_not_found", "admin %d not found", adminID)
+	}
+	if ok, _ := checkPassword(admin.Password, oldPassword); !ok {
+		return Validation("wrong_password", "old password is incorrect")
+	}
+	hash, err := hashPassword(newPassword)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if err := s.repo.UpdateAdminPassword(admin.ID, hash); err != nil {
+		return err
 	}
+	// Sesi lain (yang mungkin dipegang orang yang tahu password lama) ikut dicabut
+	return s.tokens.RevokeAllForUser(Subject(SubjectAdmin, admin.ID))
+}
 
-	return t, nil
+// ResetPassword mengganti password admin lain tanpa password lama, sekaligus membuka kunci akun
+func (s *projectService) ResetPassword(adminID uint, newPassword string) error {
+	admin, err := s.repo.GetAdminByID(adminID)
+	if err != nil {
+		return notFoundOr(err, "admin_not_found", "admin %d not found", adminID)
+	}
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.ResetAdminPassword(admin.ID, hash); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAllForUser(Subject(SubjectAdmin, admin.ID))
 }
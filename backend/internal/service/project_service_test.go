@@ -0,0 +1,39 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChangePasswordRevokesAdminSessions(t *testing.T) {
+	db := newTestDB(t)
+	tokens := NewTokenService(repository.NewTokenRepo(db), TokenConfig{Secret: "test"})
+	s := NewProjectService(repository.NewProjectRepo(db), tokens)
+
+	admin, err := s.CreateAdmin(models.CreateAdminRequest{Username: "owner", Password: "old-password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := s.Login("owner", "old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuedAt := time.Now()
+
+	if err := s.ChangePassword(admin.ID, "old-password", "new-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokens.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after password change error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if revoked, err := tokens.IsRevoked("jti", Subject(SubjectAdmin, admin.ID), issuedAt); err != nil || !revoked {
+		t.Errorf("IsRevoked(access token) = %v, %v, want true", revoked, err)
+	}
+	if _, err := s.Login("owner", "new-password"); err != nil {
+		t.Errorf("login with new password: %v", err)
+	}
+}
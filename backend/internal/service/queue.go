@@ -0,0 +1,256 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Lama pelayanan default jika poli belum punya riwayat kunjungan selesai
+	defaultServiceDuration = 10 * time.Minute
+	// Jumlah kunjungan terakhir yang dipakai untuk rata-rata lama pelayanan
+	serviceDurationSample = 50
+	elderlyAge            = 60
+)
+
+// visitTransitions: Waiting -> Called -> Examining -> Done. Pasien yang tidak
+// hadir saat dipanggil di-skip dan bisa dipanggil ulang (recall).
+var visitTransitions = map[string][]string{
+	models.VisitStatusWaiting:   {models.VisitStatusCalled, models.VisitStatusExamining, models.VisitStatusCancelled},
+	models.VisitStatusCalled:    {models.VisitStatusExamining, models.VisitStatusSkipped, models.VisitStatusCancelled},
+	models.VisitStatusSkipped:   {models.VisitStatusCalled, models.VisitStatusCancelled},
+	models.VisitStatusExamining: {models.VisitStatusDone, models.VisitStatusCancelled},
+}
+
+// EnqueuePatient membuka kunjungan baru untuk pasien yang sudah terdaftar
+func (s *hospitalService) EnqueuePatient(patientID string, req *models.EnqueueRequest) (*models.Visit, error) {
+	patient, err := s.repo.GetPatientByID(patientID)
+	if err != nil {
+		return nil, notFoundOr(err, "patient_not_found", "patient not found: %s", patientID)
+	}
+
+	var visit *models.Visit
+	err = s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		var err error
+		visit, err = openVisit(tx, patient, req.Polyclinic, req.Priority)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(TopicQueue, "visit.created", visit)
+	return visit, nil
+}
+
+// openVisit memasukkan pasien ke antrean poli dengan nomor tiket harian.
+// Satu pasien hanya boleh punya satu kunjungan aktif.
+func openVisit(tx repository.HospitalRepository, patient *models.Patient, polyclinic, priority string) (*models.Visit, error) {
+	polyclinic = strings.ToUpper(strings.TrimSpace(polyclinic))
+	if polyclinic == "" {
+		polyclinic = models.DefaultPolyclinic
+	}
+	priority, err := visitPriority(priority, patient.Dob)
+	if err != nil {
+		return nil, err
+	}
+
+	today := models.Today()
+	if err := tx.CancelStaleVisits(patient.ID, today); err != nil {
+		return nil, err
+	}
+	_, err = tx.GetActiveVisitByPatientID(patient.ID)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyQueued, patient.ID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	number, err := tx.NextTicketNumber(today, polyclinic)
+	if err != nil {
+		return nil, err
+	}
+
+	visit := &models.Visit{
+		PatientID:    patient.ID,
+		Polyclinic:   polyclinic,
+		QueueDate:    today,
+		TicketNumber: number,
+		Ticket:       fmt.Sprintf("%s-%03d", polyclinic, number),
+		Priority:     priority,
+		Status:       models.VisitStatusWaiting,
+	}
+	if err := tx.CreateVisit(visit); err != nil {
+		// Unique index kunjungan aktif: pendaftaran bersamaan untuk pasien yang sama
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, fmt.Errorf("%w: %s", ErrAlreadyQueued, patient.ID)
+		}
+		return nil, err
+	}
+	return visit, nil
+}
+
+// visitPriority memvalidasi kelas prioritas; jika kosong, pasien berumur 60+
+// otomatis masuk kelas lansia
+func visitPriority(priority, dob string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case models.PriorityEmergency:
+		return models.PriorityEmergency, nil
+	case models.PriorityElderly:
+		return models.PriorityElderly, nil
+	case models.PriorityRegular:
+		return models.PriorityRegular, nil
+	case "":
+		if born, err := time.Parse(models.DateLayout, dob); err == nil && !born.AddDate(elderlyAge, 0, 0).After(time.Now()) {
+			return models.PriorityElderly, nil
+		}
+		return models.PriorityRegular, nil
+	}
+	return "", ErrInvalidPriority
+}
+
+// GetQueue mengembalikan antrean aktif hari ini beserta posisi dan estimasi waktu tunggu
+// untuk pasien yang masih Waiting
+func (s *hospitalService) GetQueue(polyclinic string) ([]models.Visit, error) {
+	visits, err := s.repo.GetActiveVisits(models.Today(), strings.ToUpper(strings.TrimSpace(polyclinic)))
+	if err != nil {
+		return nil, err
+	}
+
+	durations := map[string]time.Duration{}
+	positions := map[string]int{}
+	for i := range visits {
+		visit := &visits[i]
+		if visit.Status != models.VisitStatusWaiting {
+			continue
+		}
+
+		duration, ok := durations[visit.Polyclinic]
+		if !ok {
+			duration = s.averageServiceDuration(visit.Polyclinic)
+			durations[visit.Polyclinic] = duration
+		}
+
+		// Estimasi = jumlah pasien Waiting di depannya x rata-rata lama pelayanan
+		ahead := positions[visit.Polyclinic]
+		positions[visit.Polyclinic]++
+		visit.Position = ahead + 1
+		visit.EstimatedWaitMinutes = int((time.Duration(ahead) * duration).Round(time.Minute).Minutes())
+	}
+	return visits, nil
+}
+
+// averageServiceDuration menghitung rata-rata lama pemeriksaan (mulai diperiksa
+// sampai selesai) dari kunjungan terakhir di poli tersebut
+func (s *hospitalService) averageServiceDuration(polyclinic string) time.Duration {
+	visits, err := s.repo.GetRecentCompletedVisits(polyclinic, serviceDurationSample)
+	if err != nil {
+		return defaultServiceDuration
+	}
+
+	var total time.Duration
+	count := 0
+	for _, visit := range visits {
+		if d := visit.ClosedAt.Sub(*visit.StartedAt); d > 0 {
+			total += d
+			count++
+		}
+	}
+	if count == 0 {
+		return defaultServiceDuration
+	}
+	return total / time.Duration(count)
+}
+
+// CallNextPatient memanggil pasien Waiting berikutnya di poli sesuai prioritas
+func (s *hospitalService) CallNextPatient(polyclinic string) (*models.Visit, error) {
+	polyclinic = strings.ToUpper(strings.TrimSpace(polyclinic))
+	if polyclinic == "" {
+		polyclinic = models.DefaultPolyclinic
+	}
+
+	var visit *models.Visit
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		var err error
+		visit, err = tx.GetNextWaitingVisitForUpdate(models.Today(), polyclinic)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: %s", ErrQueueEmpty, polyclinic)
+		}
+		if err != nil {
+			return err
+		}
+		return setVisitStatus(tx, visit, models.VisitStatusCalled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(TopicQueue, "visit.called", visit)
+	return visit, nil
+}
+
+// SkipVisit menandai pasien yang dipanggil tapi tidak hadir
+func (s *hospitalService) SkipVisit(id uint) (*models.Visit, error) {
+	return s.UpdateVisitStatus(id, models.VisitStatusSkipped)
+}
+
+// RecallVisit memanggil ulang pasien yang sebelumnya di-skip
+func (s *hospitalService) RecallVisit(id uint) (*models.Visit, error) {
+	return s.UpdateVisitStatus(id, models.VisitStatusCalled)
+}
+
+// UpdateVisitStatus mengunci kunjungan selama transisi agar dua loket tidak
+// mengubah status yang sama bersamaan
+func (s *hospitalService) UpdateVisitStatus(id uint, status string) (*models.Visit, error) {
+	var visit *models.Visit
+	err := s.repo.WithTx(func(tx repository.HospitalRepository) error {
+		var err error
+		visit, err = tx.GetVisitByIDForUpdate(id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: visit %d", ErrNotInQueue, id)
+		}
+		if err != nil {
+			return err
+		}
+		return setVisitStatus(tx, visit, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(TopicQueue, "visit.status_changed", visit)
+	return visit, nil
+}
+
+// setVisitStatus memvalidasi transisi lalu mencatat waktu tiap tahap kunjungan
+func setVisitStatus(repo repository.HospitalRepository, visit *models.Visit, status string) error {
+	allowed := false
+	for _, next := range visitTransitions[visit.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidVisitTransition, visit.Status, status)
+	}
+
+	now := time.Now()
+	switch status {
+	case models.VisitStatusCalled:
+		visit.CalledAt = &now
+	case models.VisitStatusSkipped:
+		visit.SkipCount++
+	case models.VisitStatusExamining:
+		visit.StartedAt = &now
+	case models.VisitStatusDone, models.VisitStatusCancelled:
+		visit.ClosedAt = &now
+	}
+	visit.Status = status
+	return repo.UpdateVisit(visit)
+}
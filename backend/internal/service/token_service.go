@@ -0,0 +1,265 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// Umur token default jika TokenConfig tidak mengisinya
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// TokenConfig berisi secret penandatangan JWT dan umur token
+type TokenConfig struct {
+	Secret          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// ErrInvalidRefreshToken dikembalikan untuk refresh token yang tidak dikenal, kedaluwarsa atau sudah dicabut
+var ErrInvalidRefreshToken = Unauthorized("invalid_refresh_token", "invalid or expired refresh token")
+
+// Jenis pemilik token. Subject ditulis "<jenis>:<id>", mis. "hospital_user:3".
+const (
+	SubjectAdmin        = "admin"
+	SubjectHospitalUser = "hospital_user"
+)
+
+// Subject merangkai jenis dan ID pemilik token
+func Subject(kind string, id uint) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+// ClaimsLoader membangun claims access token dari data pemilik yang terbaru.
+// repository.ErrNotFound berarti pemiliknya sudah dihapus.
+type ClaimsLoader func(id uint) (jwt.MapClaims, error)
+
+// TokenService menerbitkan access token JWT berumur pendek beserta refresh token
+// yang dirotasi setiap kali dipakai, dan mengelola daftar token yang dicabut.
+type TokenService interface {
+	// RegisterSubject dipanggil saat wiring (sebelum server jalan) oleh service
+	// pemilik akun, agar refresh selalu memakai role/nama yang terbaru
+	RegisterSubject(kind string, load ClaimsLoader)
+	Issue(subject string, claims jwt.MapClaims) (*models.TokenPair, error)
+	Refresh(refreshToken string) (*models.TokenPair, error)
+	Revoke(jti string, expiresAt time.Time, refreshToken string) error
+	// RevokeAllForUser mencabut semua sesi subject: seluruh refresh token dan
+	// access token yang sudah diterbitkan
+	RevokeAllForUser(subject string) error
+	IsRevoked(jti, subject string, issuedAt time.Time) (bool, error)
+}
+
+type tokenService struct {
+	repo       repository.TokenRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	loaders    map[string]ClaimsLoader
+}
+
+func NewTokenService(repo repository.TokenRepository, config TokenConfig) TokenService {
+	if config.AccessTokenTTL <= 0 {
+		config.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if config.RefreshTokenTTL <= 0 {
+		config.RefreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	return &tokenService{
+		repo:       repo,
+		secret:     []byte(config.Secret),
+		accessTTL:  config.AccessTokenTTL,
+		refreshTTL: config.RefreshTokenTTL,
+		loaders:    map[string]ClaimsLoader{},
+	}
+}
+
+func (s *tokenService) RegisterSubject(kind string, load ClaimsLoader) {
+	s.loaders[kind] = load
+}
+
+// Issue membuat pasangan token baru untuk sebuah login (family baru)
+func (s *tokenService) Issue(subject string, claims jwt.MapClaims) (*models.TokenPair, error) {
+	return s.issue(subject, claims, uuid.NewString(), time.Now().Add(s.refreshTTL))
+}
+
+// Refresh menukar refresh token dengan pasangan baru. Token lama langsung dicabut;
+// jika token yang sudah dicabut dipakai lagi (indikasi dicuri), seluruh family dicabut.
+func (s *tokenService) Refresh(refreshToken string) (*models.TokenPair, error) {
+	stored, err := s.repo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		if err := s.repo.RevokeRefreshFamily(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Claims dibangun ulang dari data pemilik saat ini: role yang diubah berlaku
+	// di refresh berikutnya, akun yang dihapus tidak bisa refresh lagi
+	claims, err := s.loadClaims(stored.Subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		if err := s.repo.RevokeRefreshFamily(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tandai terpakai secara atomik; jika request lain sudah lebih dulu memakai
+	// token ini, perlakukan sebagai pemakaian ulang
+	claimed, err := s.repo.ClaimRefreshToken(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		if err := s.repo.RevokeRefreshFamily(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Expiry family tidak diperpanjang oleh rotasi: token yang dicuri tidak bisa
+	// menjaga sesi tetap hidup melewati login pertama + refresh TTL
+	return s.issue(stored.Subject, claims, stored.FamilyID, stored.ExpiresAt)
+}
+
+// loadClaims memanggil ClaimsLoader sesuai jenis subject. Subject yang tidak
+// dikenal diperlakukan seperti pemilik yang sudah tidak ada.
+func (s *tokenService) loadClaims(subject string) (jwt.MapClaims, error) {
+	kind, rawID, _ := strings.Cut(subject, ":")
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	load := s.loaders[kind]
+	if err != nil || load == nil {
+		return nil, repository.ErrNotFound
+	}
+	return load(uint(id))
+}
+
+// Revoke mencabut access token (berdasarkan jti) dan, jika diberikan, seluruh family refresh token-nya
+func (s *tokenService) Revoke(jti string, expiresAt time.Time, refreshToken string) error {
+	if jti != "" {
+		if err := s.repo.RevokeAccessToken(jti, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		stored, err := s.repo.GetRefreshTokenByHash(hashToken(refreshToken))
+		if err == nil {
+			if err := s.repo.RevokeRefreshFamily(stored.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Bersihkan baris kedaluwarsa sekalian, kegagalan di sini tidak fatal
+	s.repo.DeleteExpiredTokens()
+	return nil
+}
+
+func (s *tokenService) RevokeAllForUser(subject string) error {
+	if err := s.repo.RevokeSubjectRefreshTokens(subject); err != nil {
+		return err
+	}
+	// Access token tidak disimpan, jadi dicabut lewat batas iat. Baris ini
+	// hanya perlu hidup selama access token terlama yang mungkin masih berlaku.
+	now := time.Now()
+	return s.repo.RevokeSubject(subject, now, now.Add(s.accessTTL))
+}
+
+func (s *tokenService) IsRevoked(jti, subject string, issuedAt time.Time) (bool, error) {
+	if jti == "" || subject == "" {
+		// Token tanpa jti/sub diterbitkan sebelum revocation ada, anggap dicabut
+		return true, nil
+	}
+	revoked, err := s.repo.IsAccessTokenRevoked(jti)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	revocation, err := s.repo.GetSubjectRevocation(subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// iat hanya presisi detik: token yang terbit di detik yang sama dengan
+	// pencabutan ikut ditolak
+	return issuedAt.Unix() <= revocation.RevokedBefore.Unix(), nil
+}
+
+// issue menerbitkan access token dan refresh token baru di family familyID.
+// Semua refresh token satu family berbagi expiresAt yang sama.
+func (s *tokenService) issue(subject string, claims jwt.MapClaims, familyID string, expiresAt time.Time) (*models.TokenPair, error) {
+	now := time.Now()
+	accessClaims := jwt.MapClaims{}
+	for k, v := range claims {
+		accessClaims[k] = v
+	}
+	accessClaims["sub"] = subject
+	accessClaims["jti"] = uuid.NewString()
+	accessClaims["iat"] = now.Unix()
+	accessClaims["exp"] = now.Add(s.accessTTL).Unix()
+
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	record := &models.RefreshToken{
+		TokenHash: hashToken(refresh),
+		FamilyID:  familyID,
+		Subject:   subject,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.CreateRefreshToken(record); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken: refresh token hanya disimpan sebagai hash agar bocornya DB tidak membocorkan token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
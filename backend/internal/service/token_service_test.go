@@ -0,0 +1,124 @@
+package services
+
+import (
+	"backend/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newTestTokenService(t *testing.T) (*tokenService, repository.TokenRepository) {
+	t.Helper()
+	repo := repository.NewTokenRepo(newTestDB(t))
+	return NewTokenService(repo, TokenConfig{Secret: "test"}).(*tokenService), repo
+}
+
+func TestRefreshKeepsFamilyExpiry(t *testing.T) {
+	s, repo := newTestTokenService(t)
+	s.RegisterSubject(SubjectHospitalUser, func(id uint) (jwt.MapClaims, error) {
+		return jwt.MapClaims{"user_id": id}, nil
+	})
+
+	pair, err := s.Issue(Subject(SubjectHospitalUser, 1), jwt.MapClaims{"user_id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.GetRefreshTokenByHash(hashToken(pair.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	rotated, err := s.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.GetRefreshTokenByHash(hashToken(rotated.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.ExpiresAt.Equal(first.ExpiresAt) {
+		t.Errorf("rotated expiry = %s, want family expiry %s", second.ExpiresAt, first.ExpiresAt)
+	}
+}
+
+func TestRefreshRebuildsClaimsFromSubject(t *testing.T) {
+	s, _ := newTestTokenService(t)
+	role := "doctor"
+	deleted := false
+	s.RegisterSubject(SubjectHospitalUser, func(id uint) (jwt.MapClaims, error) {
+		if deleted {
+			return nil, repository.ErrNotFound
+		}
+		return jwt.MapClaims{"user_id": id, "role": role}, nil
+	})
+
+	pair, err := s.Issue(Subject(SubjectHospitalUser, 7), jwt.MapClaims{"user_id": 7, "role": role})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Role diturunkan setelah login: refresh memakai role baru
+	role = "front-desk"
+	pair, err = s.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(pair.AccessToken, claims, func(*jwt.Token) (interface{}, error) { return s.secret, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if claims["role"] != "front-desk" || claims["sub"] != "hospital_user:7" {
+		t.Errorf("claims = %v, want role front-desk and sub hospital_user:7", claims)
+	}
+
+	// Akun dihapus: refresh ditolak
+	deleted = true
+	if _, err := s.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh for deleted user error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRevokeAllForUser(t *testing.T) {
+	s, _ := newTestTokenService(t)
+	s.RegisterSubject(SubjectAdmin, func(id uint) (jwt.MapClaims, error) {
+		return jwt.MapClaims{"admin_id": id}, nil
+	})
+
+	subject := Subject(SubjectAdmin, 1)
+	other := Subject(SubjectAdmin, 2)
+	pair, err := s.Issue(subject, jwt.MapClaims{"admin_id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPair, err := s.Issue(other, jwt.MapClaims{"admin_id": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuedAt := time.Now()
+
+	if err := s.RevokeAllForUser(subject); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after RevokeAllForUser error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if revoked, err := s.IsRevoked("jti", subject, issuedAt); err != nil || !revoked {
+		t.Errorf("IsRevoked(old access token) = %v, %v, want true", revoked, err)
+	}
+	// Login baru setelah pencabutan tetap berlaku
+	if revoked, err := s.IsRevoked("jti", subject, issuedAt.Add(2*time.Second)); err != nil || revoked {
+		t.Errorf("IsRevoked(new access token) = %v, %v, want false", revoked, err)
+	}
+
+	// Sesi subject lain tidak ikut tercabut
+	if revoked, err := s.IsRevoked("jti", other, issuedAt); err != nil || revoked {
+		t.Errorf("IsRevoked(other subject) = %v, %v, want false", revoked, err)
+	}
+	if _, err := s.Refresh(otherPair.RefreshToken); err != nil {
+		t.Errorf("refresh for other subject: %v", err)
+	}
+}
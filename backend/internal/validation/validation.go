@@ -0,0 +1,221 @@
+package validation
+
+import (
+	"backend/internal/models"
+	"backend/internal/service"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MedicineLookup dipakai aturan yang butuh data, mis. medicine_id harus terdaftar
+type MedicineLookup interface {
+	GetMedicinesByIDs(ids []string) ([]models.Medicine, error)
+}
+
+// Validator memeriksa request DTO sebelum diteruskan ke service: tag `validate`
+// di struct, lalu aturan domain yang butuh database. Semua field yang salah
+// dikumpulkan sekaligus.
+type Validator struct {
+	validate  *validator.Validate
+	medicines MedicineLookup
+}
+
+// New membuat Validator; medicines dipakai untuk mengecek medicine_id di resep
+func New(medicines MedicineLookup) *Validator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Nama field di pesan error mengikuti nama JSON, bukan nama field Go
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	v.RegisterValidation("date", isDate)
+	v.RegisterValidation("notfuture", isNotFuture)
+
+	return &Validator{validate: v, medicines: medicines}
+}
+
+// Struct memvalidasi pointer ke request DTO. Mengembalikan *Error jika ada field
+// yang tidak valid, atau error lain jika lookup ke database gagal.
+func (v *Validator) Struct(req interface{}) error {
+	var fields []FieldError
+	if err := v.validate.Struct(req); err != nil {
+		verrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return err
+		}
+		for _, fe := range verrs {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Message: message(fe),
+			})
+		}
+	}
+
+	more, err := v.domainRules(req)
+	if err != nil {
+		return err
+	}
+	fields = append(fields, more...)
+
+	if len(fields) > 0 {
+		return &Error{Fields: fields}
+	}
+	return nil
+}
+
+// domainRules menjalankan aturan yang tidak bisa diekspresikan dengan tag
+func (v *Validator) domainRules(req interface{}) ([]FieldError, error) {
+	switch req := req.(type) {
+	case *models.CreatePrescriptionRequest:
+		fields := patientReference(req)
+		more, err := v.knownMedicines(req.Items)
+		return append(fields, more...), err
+	}
+	return nil, nil
+}
+
+// patientReference: resep menunjuk pasien lewat patient_id, atau patient_name + patient_dob
+func patientReference(req *models.CreatePrescriptionRequest) []FieldError {
+	if req.PatientID != "" || (req.PatientName != "" && req.PatientDob != "") {
+		return nil
+	}
+	return []FieldError{{
+		Field:   "patient_id",
+		Rule:    "required",
+		Message: "is required unless patient_name and patient_dob are given",
+	}}
+}
+
+// knownMedicines memastikan setiap medicine_id ada di katalog obat
+func (v *Validator) knownMedicines(items []models.CreatePrescriptionItemRequest) ([]FieldError, error) {
+	var ids []string
+	for _, item := range items {
+		if item.MedicineID != "" {
+			ids = append(ids, item.MedicineID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	medicines, err := v.medicines.GetMedicinesByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(medicines))
+	for _, m := range medicines {
+		known[m.ID] = true
+	}
+
+	var fields []FieldError
+	for i, item := range items {
+		if item.MedicineID != "" && !known[item.MedicineID] {
+			fields = append(fields, FieldError{
+				Field:   fmt.Sprintf("items[%d].medicine_id", i),
+				Rule:    "exists",
+				Message: fmt.Sprintf("unknown medicine %q", item.MedicineID),
+			})
+		}
+	}
+	return fields, nil
+}
+
+// fieldPath membuang nama struct di depan namespace validator,
+// "CreatePrescriptionRequest.items[0].qty" menjadi "items[0].qty"
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+// isDate menerima string YYYY-MM-DD; string kosong diserahkan ke required
+func isDate(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "" {
+		return true
+	}
+	_, err := models.ParseDate(s)
+	return err == nil
+}
+
+// isNotFuture menolak tanggal setelah hari ini, untuk field string maupun models.Date.
+// Format yang salah diserahkan ke aturan date.
+func isNotFuture(fl validator.FieldLevel) bool {
+	var d models.Date
+	switch value := fl.Field().Interface().(type) {
+	case models.Date:
+		d = value
+	case string:
+		parsed, err := models.ParseDate(value)
+		if err != nil {
+			return true
+		}
+		d = parsed
+	default:
+		return false
+	}
+	return d.IsZero() || !d.After(models.Today().Time)
+}
+
+func message(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be at least " + param
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s item(s)", param)
+		}
+		return fmt.Sprintf("must be at least %s characters", param)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "date":
+		return "must be a date in YYYY-MM-DD format"
+	case "notfuture":
+		return "cannot be in the future"
+	}
+	return "is invalid (" + fe.Tag() + ")"
+}
+
+// FieldError adalah satu field yang tidak lolos validasi
+type FieldError struct {
+	Field   string `json:"field"` // path JSON, mis. items[0].qty
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error berisi semua field yang tidak valid dari satu request (400 validation_failed)
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	f := e.Fields[0]
+	msg := fmt.Sprintf("%s %s", f.Field, f.Message)
+	if len(e.Fields) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Fields)-1)
+	}
+	return msg
+}
+
+func (e *Error) Kind() services.Kind { return services.KindValidation }
+func (e *Error) Code() string        { return "validation_failed" }
+func (e *Error) Details() map[string]interface{} {
+	return map[string]interface{}{"fields": e.Fields}
+}